@@ -5,10 +5,13 @@ go 1.21
 toolchain go1.22.0
 
 require (
+	github.com/BurntSushi/toml v1.4.0
 	github.com/ajstarks/svgo v0.0.0-20211024235047-1546f124cd8b
 	github.com/chromedp/cdproto v0.0.0-20240512230644-b3296df1660c
 	github.com/chromedp/chromedp v0.9.5
+	github.com/fsnotify/fsnotify v1.7.0
 	github.com/gorilla/mux v1.8.1
+	github.com/hexops/gotextdiff v1.0.3
 	github.com/microcosm-cc/bluemonday v1.0.26
 	github.com/peter-mount/go-build v0.0.0-20240514073133-657b3becdcba
 	github.com/peter-mount/go-kernel/v2 v2.0.3-0.20240514072728-897c39470117
@@ -16,6 +19,7 @@ require (
 	github.com/xuri/excelize/v2 v2.8.1
 	golang.org/x/net v0.25.0
 	gopkg.in/yaml.v2 v2.4.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
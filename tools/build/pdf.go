@@ -30,10 +30,14 @@ func (s *PDF) documentation(root makefile.Builder, parentTarget target.Builder,
 
 	var targets []string
 
+	// A section is printed within the pdf of the book including it as a volume, so has no pdf of its own
 	_ = s.BookShelf.
 		Books().
+		Filter(func(book *hugo.Book) bool {
+			return !book.Section() && !book.Translation()
+		}).
 		ForEach(func(book *hugo.Book) error {
-			bookTarget := filepath.Join(siteDir, "static/book", book.ID+".pdf")
+			bookTarget := book.PDFFile()
 			targets = append(targets, bookTarget)
 			root.Rule(bookTarget, pdfDependencies...).
 				Mkdir(filepath.Dir(bookTarget)).
@@ -2,9 +2,11 @@ package pdf
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/runtime"
 	"github.com/chromedp/chromedp"
 	"github.com/peter-mount/documentation/tools/gensite"
 	"github.com/peter-mount/documentation/tools/gensite/hugo"
@@ -66,13 +68,62 @@ func (p *PDF) Start() error {
 		})
 }
 
+// appendVolumes fetches the print page of each volume, appending its content to the current page.
+// Relative sources are resolved against the volume's page as it is appended to the book's.
+const appendVolumes = `(async urls => {
+	const main = doc => doc.querySelector("main") || doc.querySelector(".td-main-new, .td-main") || doc.body;
+	const dest = main(document);
+	for (const url of urls) {
+		const resp = await fetch(url);
+		if (!resp.ok) {
+			throw new Error(url + ": " + resp.status + " " + resp.statusText);
+		}
+		const doc = new DOMParser().parseFromString(await resp.text(), "text/html");
+		doc.querySelectorAll("[src]").forEach(e => e.setAttribute("src", new URL(e.getAttribute("src"), url).href));
+		const volume = document.createElement("div");
+		volume.className = "printPageBreak";
+		volume.append(...Array.from(main(doc).children).map(e => document.importNode(e, true)));
+		dest.appendChild(volume);
+	}
+	// Wait for any images in the volumes to load before printing
+	await Promise.all(Array.from(dest.querySelectorAll("img")).
+		filter(img => !img.complete).
+		map(img => new Promise(resolve => img.onload = img.onerror = resolve)));
+})(%s)`
+
 // print a specific pdf page.
 func (p *PDF) printToPDF(book *hugo.Book, destFile string) chromedp.Tasks {
-	url := p.webserver.WebPath("%s/_print/", strings.ToLower(book.WebPath()))
+	var urls []string
+	for _, webPath := range book.PrintPaths() {
+		urls = append(urls, p.webserver.WebPath("%s/_print/", strings.ToLower(webPath)))
+	}
+
+	tasks := chromedp.Tasks{
+		chromedp.Navigate(urls[0]),
+	}
+
+	// Volumes follow the book's own pages, in the order they were declared
+	if len(urls) > 1 {
+		// Marshalling a string slice cannot fail
+		volumes, _ := json.Marshal(urls[1:])
+		tasks = append(tasks, chromedp.Evaluate(fmt.Sprintf(appendVolumes, volumes), nil,
+			func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+				return p.WithAwaitPromise(true)
+			}))
+	}
 
-	return chromedp.Tasks{
-		chromedp.Navigate(url),
+	return append(tasks,
 		chromedp.ActionFunc(func(ctx context.Context) error {
+			header, err := book.Expand(p.config.Header, p.config)
+			if err != nil {
+				return err
+			}
+
+			footer, err := book.Expand(p.config.Footer, p.config)
+			if err != nil {
+				return err
+			}
+
 			buf, _, err := page.PrintToPDF().
 				WithPrintBackground(p.config.PrintBackground).
 				WithMarginTop(p.config.Margin.Top).
@@ -83,8 +134,8 @@ func (p *PDF) printToPDF(book *hugo.Book, destFile string) chromedp.Tasks {
 				WithPaperWidth(p.config.Width).
 				WithPaperHeight(p.config.Height).
 				WithDisplayHeaderFooter(!p.config.DisableHeaderFooter).
-				WithHeaderTemplate(book.Expand(p.config.Header)).
-				WithFooterTemplate(book.Expand(p.config.Footer)).
+				WithHeaderTemplate(header).
+				WithFooterTemplate(footer).
 				Do(ctx)
 
 			if err != nil {
@@ -92,8 +143,8 @@ func (p *PDF) printToPDF(book *hugo.Book, destFile string) chromedp.Tasks {
 			}
 
 			return util.ByteFileHandler(buf).
-				Write(destFile, book.Modified())
+				Write(ctx, destFile, book.Modified())
 			//Write("public/static/book/"+book.ID+".pdf", book.Modified())
 		}),
-	}
+	)
 }
@@ -2,12 +2,17 @@ package main
 
 import (
 	"fmt"
+	"github.com/peter-mount/documentation/tools/gensite/generator/arm"
 	"github.com/peter-mount/documentation/tools/gensite/generator/autodoc"
 	"github.com/peter-mount/documentation/tools/gensite/generator/bbc"
 	"github.com/peter-mount/documentation/tools/gensite/generator/chip"
 	"github.com/peter-mount/documentation/tools/gensite/generator/m6502"
 	"github.com/peter-mount/documentation/tools/gensite/generator/m68k"
+	"github.com/peter-mount/documentation/tools/gensite/generator/plugin"
 	"github.com/peter-mount/documentation/tools/gensite/generator/svg"
+	"github.com/peter-mount/documentation/tools/gensite/generator/taxonomy"
+	"github.com/peter-mount/documentation/tools/gensite/generator/volume"
+	"github.com/peter-mount/documentation/tools/gensite/generator/z80"
 	"github.com/peter-mount/documentation/tools/gensite/hugo"
 	"github.com/peter-mount/documentation/tools/gensite/telstar"
 	"github.com/peter-mount/go-kernel/v2"
@@ -20,9 +25,14 @@ func main() {
 		&bbc.BBC{},
 		&m6502.M6502{},
 		&m68k.M68k{},
+		&z80.Z80{},
+		&arm.ARM{},
 		&chip.Chip{},
 		&autodoc.Autodoc{},
 		&svg.SVG{},
+		&volume.Volume{},
+		&taxonomy.Taxonomy{},
+		&plugin.Plugins{},
 		&telstar.Service{},
 		// Core modules. Have these after the generators, so they pick up the new content
 		&hugo.Hugo{},
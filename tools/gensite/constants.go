@@ -1,14 +1,20 @@
 package tools
 
-// Worker task priorities
+// Worker task priorities.
+// These order the work queued by generators, the generators themselves are ordered by their declared dependencies.
 const (
 	PriorityImmediate = -100 // Run task before any others
 	PriorityApi       = 20   // API generation
-	PriorityIndices   = 25   // 6502 api indices
 	PriorityAutodoc   = 30   // Autodoc generation
-	PriorityChip      = 50   // Chip reference tables
+	PriorityChip      = 50   // Chip SVG generation
 	PrioritySVG       = 70   // SVG generation
+	PriorityWatch     = 95   // Watch mode starts once the initial content has been generated
+	PriorityPrune     = 98   // Stale generated files are removed before hugo sees them
 	PriorityHugo      = 100  // Hugo page generation
 	PriorityPDF       = 400  // PDF Generation
 	PriorityExcel     = 500  // Priority for Excel generation
+	PriorityCache     = 550  // Build cache is saved once all content has been generated
+	PriorityManifest  = 560  // Build manifest is written once all content has been generated
+	PriorityReport    = 900  // Generator timings are reported once everything has been generated
+	PriorityDryRun    = 1000 // Report what would have changed once everything else has run
 )
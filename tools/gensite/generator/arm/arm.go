@@ -0,0 +1,85 @@
+package arm
+
+import (
+	"github.com/peter-mount/documentation/tools/gensite/generator"
+	"github.com/peter-mount/documentation/tools/gensite/hugo"
+	"github.com/peter-mount/documentation/tools/gensite/util/schema"
+	util2 "github.com/peter-mount/go-kernel/v2/util"
+	"github.com/peter-mount/go-kernel/v2/util/task"
+)
+
+const (
+	OpcodesStage = "arm.opcodes" // Instructions have been extracted from a book
+)
+
+// ARM generates the instruction reference pages of the ARM book.
+//
+// ARM instructions do not have an opcode as such, instead each instruction has one or more encodings, a bit pattern
+// containing the condition code, S flag & operands. These are declared in the codes front matter of a page with the
+// format of each encoding, see Encoding, e.g.
+//
+//	codes:
+//	  - op: "ADC"
+//	    encoding: "A1"
+//	    format: "cond 0010101 S Rn Rd imm12"
+//	    syntax:
+//	      - "ADC{S}{<c>}{<q>} {<Rd>,} <Rn>, #<const>"
+type ARM struct {
+	generator    *generator.Generator     `kernel:"inject"` // Generator
+	excel        *generator.Excel         `kernel:"inject"` // Excel
+	index        *hugo.ContentIndex       `kernel:"inject"` // ContentIndex
+	extracted    util2.Set[string]        // Set of book ID's so that we run once per book
+	instructions util2.Map[*Instructions] // Instructions extracted per book
+}
+
+func (s *ARM) Start() error {
+	s.extracted = util2.NewSyncSet[string]()
+	s.instructions = util2.NewSyncMap[*Instructions]()
+
+	s.generator.
+		Register("armOpcodes",
+			task.Of(s.extractOpcodes),
+			generator.Provides(OpcodesStage),
+			generator.Translated(),
+			generator.Validates("op", schema.String()),
+			generator.Validates("codes", codesSchema())).
+		Register("armEncodingIndex",
+			task.Of(s.writeEncodingIndex),
+			generator.Needs(OpcodesStage),
+			generator.Translated()).
+		Register("armInstructionIndex",
+			task.Of(s.writeInstructionIndex),
+			generator.Needs(OpcodesStage),
+			generator.Translated()).
+		Register("armTable",
+			task.Of(s.writeTable),
+			generator.Needs(OpcodesStage)).
+		Register("armDataset",
+			task.Of(s.writeDataset),
+			generator.Needs(OpcodesStage)).
+		OnReset(s.reset)
+
+	return nil
+}
+
+func codesSchema() *schema.Schema {
+	return schema.List(schema.Map().
+		Field("op", schema.String()).
+		Field("encoding", schema.String()).
+		Field("format", schema.String()).
+		Field("syntax", schema.List(schema.String())))
+}
+
+// reset discards the instructions extracted from a book so that it can be regenerated
+func (s *ARM) reset(book *hugo.Book) error {
+	s.extracted.Remove(book.ID)
+	s.instructions.Remove(book.ID)
+	return nil
+}
+
+// Instructions returns the Instructions extracted for a Book
+func (s *ARM) Instructions(book *hugo.Book) *Instructions {
+	return s.instructions.ComputeIfAbsent(book.ID, func(_ string) *Instructions {
+		return &Instructions{}
+	})
+}
@@ -0,0 +1,46 @@
+package arm
+
+import (
+	"context"
+	"github.com/peter-mount/documentation/tools/gensite/generator"
+	"github.com/peter-mount/documentation/tools/gensite/util"
+)
+
+// DatasetEntry is an Instruction in the machine-readable dataset of the instruction set.
+// Unlike the other processors ARM has encodings not opcodes, so it has its own fields.
+type DatasetEntry struct {
+	Op          string   `json:"op" yaml:"op"`                                 // Mnemonic
+	Encoding    string   `json:"encoding,omitempty" yaml:"encoding,omitempty"` // Name of the encoding, e.g. "A1"
+	Format      string   `json:"format" yaml:"format"`                         // Format of the encoding
+	Pattern     string   `json:"pattern" yaml:"pattern"`                       // Bit pattern, see Encoding.Pattern
+	Width       int      `json:"width" yaml:"width"`                           // Width in bits
+	Conditional bool     `json:"conditional" yaml:"conditional"`               // Has a condition code
+	SetsFlags   bool     `json:"setsFlags" yaml:"setsFlags"`                   // Has the S flag
+	Operands    []string `json:"operands,omitempty" yaml:"operands,omitempty"` // Operand fields
+	Syntax      []string `json:"syntax,omitempty" yaml:"syntax,omitempty"`     // Assembler syntax
+}
+
+func (s *ARM) writeDataset(ctx context.Context) error {
+	book := generator.GetBook(ctx)
+
+	var entries []*DatasetEntry
+	for _, i := range s.Instructions(book).ByEncoding() {
+		entries = append(entries, &DatasetEntry{
+			Op:          i.Op,
+			Encoding:    i.Form,
+			Format:      i.Encoding.String(),
+			Pattern:     i.Encoding.Pattern(),
+			Width:       i.Encoding.Width,
+			Conditional: i.Encoding.Conditional(),
+			SetsFlags:   i.Encoding.SetsFlags(),
+			Operands:    i.Encoding.Operands(),
+			Syntax:      i.Syntax,
+		})
+	}
+
+	err := util.JsonFileHandler(entries).Write(ctx, book.StaticPath("opcodes.json"), book.Modified())
+	if err != nil {
+		return err
+	}
+	return util.YamlFileHandler(entries).Write(ctx, book.StaticPath("opcodes.yaml"), book.Modified())
+}
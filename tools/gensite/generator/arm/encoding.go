@@ -0,0 +1,166 @@
+package arm
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	CondField  = "cond" // Condition code field
+	FlagsField = "S"    // Field set if the instruction updates the flags
+)
+
+// Field is a field within an Encoding, either fixed bits or an operand
+type Field struct {
+	Name  string // Name of the field, blank for fixed bits
+	Width int    // Width in bits
+	Bits  string // Fixed bits, blank for a named field
+}
+
+// Encoding is the bit pattern of an instruction.
+//
+// It is parsed from a format, the fields from the most significant bit separated by spaces. Each field is either
+// fixed bits, e.g. "0010101", or a named field with its width, e.g. "imm12:12". The width can be omitted for the
+// cond field, registers like Rn or RdHi, single letter fields like S & names ending in their width like imm12.
+//
+// So ADC (immediate) in the ARM instruction set is "cond 0010101 S Rn Rd imm12".
+// An encoding is either 16 or 32 bits wide, as Thumb instructions can be either.
+type Encoding struct {
+	Fields []Field // Fields from the most significant bit
+	Width  int     // Width in bits
+}
+
+var (
+	registerRegex = regexp.MustCompile(`^R[a-z]+(Hi|Lo)?\d?$`)
+	widthRegex    = regexp.MustCompile(`^[A-Za-z_]+?(\d+)$`)
+)
+
+func ParseEncoding(format string) (*Encoding, error) {
+	e := &Encoding{}
+	for _, s := range strings.Fields(format) {
+		f, err := parseField(s)
+		if err != nil {
+			return nil, err
+		}
+		e.Fields = append(e.Fields, f)
+		e.Width += f.Width
+	}
+
+	if e.Width != 16 && e.Width != 32 {
+		return nil, fmt.Errorf("encoding is %d bits not 16 or 32", e.Width)
+	}
+	return e, nil
+}
+
+func parseField(s string) (Field, error) {
+	if name, width, ok := strings.Cut(s, ":"); ok {
+		w, err := strconv.Atoi(width)
+		if err != nil || w < 1 || name == "" {
+			return Field{}, fmt.Errorf("invalid field %q", s)
+		}
+		return Field{Name: name, Width: w}, nil
+	}
+
+	switch {
+	case strings.Trim(s, "01") == "":
+		return Field{Width: len(s), Bits: s}, nil
+	case s == CondField, registerRegex.MatchString(s):
+		return Field{Name: s, Width: 4}, nil
+	case len(s) == 1:
+		return Field{Name: s, Width: 1}, nil
+	}
+
+	if m := widthRegex.FindStringSubmatch(s); m != nil {
+		if w, err := strconv.Atoi(m[1]); err == nil && w > 0 {
+			return Field{Name: s, Width: w}, nil
+		}
+	}
+
+	return Field{}, fmt.Errorf("width of field %q unknown, use %s:width", s, s)
+}
+
+// Pattern returns the bit pattern of the encoding in nibbles, fixed bits as 0 or 1, c for the condition,
+// S for the S flag & x for the bits of any other field, e.g. "cccc 0010 101S xxxx xxxx xxxx xxxx xxxx"
+func (e *Encoding) Pattern() string {
+	var b strings.Builder
+	n := 0
+	for _, f := range e.Fields {
+		for i := 0; i < f.Width; i++ {
+			if n > 0 && n%4 == 0 {
+				b.WriteByte(' ')
+			}
+			n++
+
+			switch {
+			case f.Bits != "":
+				b.WriteByte(f.Bits[i])
+			case f.Name == CondField:
+				b.WriteByte('c')
+			case f.Name == FlagsField:
+				b.WriteByte('S')
+			default:
+				b.WriteByte('x')
+			}
+		}
+	}
+	return b.String()
+}
+
+// Order returns the fixed bits of the encoding, with any other bits 0, so encodings can be ordered by their pattern
+func (e *Encoding) Order() uint32 {
+	var o uint32
+	for _, f := range e.Fields {
+		for i := 0; i < f.Width; i++ {
+			o <<= 1
+			if f.Bits != "" && f.Bits[i] == '1' {
+				o |= 1
+			}
+		}
+	}
+	return o
+}
+
+// Conditional returns true if the encoding has a condition code
+func (e *Encoding) Conditional() bool {
+	return e.has(CondField)
+}
+
+// SetsFlags returns true if the encoding has the S flag to update the flags
+func (e *Encoding) SetsFlags() bool {
+	return e.has(FlagsField)
+}
+
+func (e *Encoding) has(name string) bool {
+	for _, f := range e.Fields {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Operands returns the names of the operand fields, i.e. all named fields except the condition & S flag
+func (e *Encoding) Operands() []string {
+	var r []string
+	for _, f := range e.Fields {
+		if f.Name != "" && f.Name != CondField && f.Name != FlagsField {
+			r = append(r, f.Name)
+		}
+	}
+	return r
+}
+
+// String returns the format of the encoding
+func (e *Encoding) String() string {
+	var a []string
+	for _, f := range e.Fields {
+		if f.Bits != "" {
+			a = append(a, f.Bits)
+		} else {
+			a = append(a, f.Name)
+		}
+	}
+	return strings.Join(a, " ")
+}
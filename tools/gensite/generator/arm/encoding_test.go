@@ -0,0 +1,50 @@
+package arm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseEncoding(t *testing.T) {
+	for _, test := range []struct {
+		format   string
+		pattern  string
+		cond     bool
+		flags    bool
+		operands string
+	}{
+		{"cond 0010101 S Rn Rd imm12", "cccc 0010 101S xxxx xxxx xxxx xxxx xxxx", true, true, "Rn Rd imm12"},
+		{"11110 i 0 1010 S Rn 0 imm3 Rd imm8", "1111 0x01 010S xxxx 0xxx xxxx xxxx xxxx", false, true, "i Rn imm3 Rd imm8"},
+		{"010000 0101 Rm:3 Rdn:3", "0100 0001 01xx xxxx", false, false, "Rm Rdn"},
+		{"cond 0000101 S RdHi RdLo Rm 1001 Rn", "cccc 0000 101S xxxx xxxx xxxx 1001 xxxx", true, true, "RdHi RdLo Rm Rn"},
+	} {
+		e, err := ParseEncoding(test.format)
+		if err != nil {
+			t.Errorf("%q: %v", test.format, err)
+			continue
+		}
+		if got := e.Pattern(); got != test.pattern {
+			t.Errorf("%q pattern got %q want %q", test.format, got, test.pattern)
+		}
+		if e.Conditional() != test.cond || e.SetsFlags() != test.flags {
+			t.Errorf("%q got cond %v flags %v", test.format, e.Conditional(), e.SetsFlags())
+		}
+		if got := strings.Join(e.Operands(), " "); got != test.operands {
+			t.Errorf("%q operands got %q want %q", test.format, got, test.operands)
+		}
+	}
+
+	for _, format := range []string{"", "cond 0010101 S Rn Rd", "cond 0010101 S Rn Rd imm", "cond 0010101 S Rn Rd imm:x"} {
+		if _, err := ParseEncoding(format); err == nil {
+			t.Errorf("%q did not fail", format)
+		}
+	}
+}
+
+func TestEncoding_Order(t *testing.T) {
+	a, _ := ParseEncoding("cond 0010101 S Rn Rd imm12")
+	b, _ := ParseEncoding("cond 0000101 S Rn Rd imm5 type:2 0 Rm")
+	if a.Order() != 0x02A00000 || b.Order() >= a.Order() {
+		t.Errorf("got %08X %08X", a.Order(), b.Order())
+	}
+}
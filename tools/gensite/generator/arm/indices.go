@@ -0,0 +1,66 @@
+package arm
+
+import (
+	"context"
+	"fmt"
+	"github.com/peter-mount/documentation/tools/gensite/generator"
+	"github.com/peter-mount/documentation/tools/gensite/generator/assembly"
+	strings2 "github.com/peter-mount/go-kernel/v2/util/strings"
+	"html"
+	"strings"
+)
+
+func (s *ARM) writeEncodingIndex(ctx context.Context) error {
+	book := generator.GetBook(ctx)
+	inst := s.Instructions(book).ByEncoding()
+
+	gen := &assembly.IndexGenerator{
+		Name:  "encoding",
+		Title: "Instruction List by encoding",
+		Desc:  "",
+		Class: "opIndex1",
+		// A table for the 16 bit & another for the 32 bit encodings
+		Paginator: func(rowCount int, entry interface{}) bool {
+			return entry.(*Instruction).Encoding.Width != inst[rowCount-1].Encoding.Width
+		},
+		Header: func(slice strings2.StringSlice, rowCount int) strings2.StringSlice {
+			if len(inst) > 0 {
+				slice = append(slice, fmt.Sprintf("<caption>%d bit encodings</caption>", inst[rowCount].Encoding.Width))
+			}
+			return indexHeader(slice, rowCount)
+		},
+		Body: indexBody,
+	}
+	return gen.Write(ctx, book, assembly.Iterator(inst))
+}
+
+func (s *ARM) writeInstructionIndex(ctx context.Context) error {
+	book := generator.GetBook(ctx)
+
+	gen := &assembly.IndexGenerator{
+		Name:   "instructions",
+		Title:  "Instruction List by name",
+		Desc:   "",
+		Class:  "opIndex1",
+		Header: indexHeader,
+		Body:   indexBody,
+	}
+	return gen.Write(ctx, book, assembly.Iterator(s.Instructions(book).ByMnemonic()))
+}
+
+// indexHeader shared
+func indexHeader(slice strings2.StringSlice, _ int) strings2.StringSlice {
+	return append(slice, "<thead><tr>",
+		"<th>Instruction</th>",
+		"<th>Encoding</th>",
+		"<th>Pattern</th>",
+		"<th>Operands</th>",
+		"</tr></thead>")
+}
+
+// indexBody shared
+func indexBody(slice strings2.StringSlice, _ int, entry interface{}) strings2.StringSlice {
+	i := entry.(*Instruction)
+	return append(slice, fmt.Sprintf("<tr><td>%s</td><td>%s</td><td><code>%s</code></td><td>%s</td></tr>",
+		i.Format(), html.EscapeString(i.Form), i.Encoding.Pattern(), html.EscapeString(strings.Join(i.Encoding.Operands(), ", "))))
+}
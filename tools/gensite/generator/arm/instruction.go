@@ -0,0 +1,113 @@
+package arm
+
+import (
+	"context"
+	"fmt"
+	"github.com/peter-mount/documentation/tools/gensite/generator"
+	"github.com/peter-mount/documentation/tools/gensite/hugo"
+	"github.com/peter-mount/go-kernel/v2/log"
+	util2 "github.com/peter-mount/go-kernel/v2/util"
+	"html"
+	"sort"
+)
+
+// Instruction is an encoding of an instruction
+type Instruction struct {
+	Op       string    // Mnemonic
+	Form     string    // Name of the encoding, e.g. "A1" or "T3" (optional)
+	Syntax   []string  // Assembler syntax (optional)
+	Encoding *Encoding // Encoding of the instruction
+}
+
+// Instructions extracted from a book
+type Instructions struct {
+	instructions []*Instruction
+}
+
+func (s *ARM) extractOpcodes(ctx context.Context) error {
+	book := generator.GetBook(ctx)
+	instructions := s.Instructions(book)
+
+	// Only run once per Book ID
+	if !s.extracted.Add(book.ID) {
+		return nil
+	}
+
+	log.Println("Scanning ARM instructions")
+	return s.index.Walk(ctx, book, hugo.FrontMatterActionOf().
+		OtherExists("codes", instructions.extract))
+}
+
+func (i *Instructions) extract(ctx context.Context, _ *hugo.FrontMatter) error {
+	return util2.ForEachInterface(ctx.Value("other"), func(e interface{}) error {
+		return util2.IfMap(e, func(m map[interface{}]interface{}) error {
+			inst := &Instruction{
+				Op:   util2.IfMapEntryString(m, "op"),
+				Form: util2.IfMapEntryString(m, "encoding"),
+			}
+
+			format := util2.IfMapEntryString(m, "format")
+			enc, err := ParseEncoding(format)
+			if err != nil {
+				return fmt.Errorf("%s: %s format %q: %w", hugo.FilePath(ctx), inst.Op, format, err)
+			}
+			inst.Encoding = enc
+
+			err = util2.IfMapEntry(m, "syntax", func(v interface{}) error {
+				return util2.ForEachInterface(v, func(s interface{}) error {
+					inst.Syntax = append(inst.Syntax, util2.DecodeString(s, ""))
+					return nil
+				})
+			})
+			if err != nil {
+				return err
+			}
+
+			i.instructions = append(i.instructions, inst)
+			return nil
+		})
+	})
+}
+
+// ByEncoding returns the instructions ordered by their Encoding, 16 bit Thumb encodings first
+func (i *Instructions) ByEncoding() []*Instruction {
+	r := append([]*Instruction{}, i.instructions...)
+	sort.SliceStable(r, func(a, b int) bool {
+		ea, eb := r[a].Encoding, r[b].Encoding
+		switch {
+		case ea.Width != eb.Width:
+			return ea.Width < eb.Width
+		case ea.Order() != eb.Order():
+			return ea.Order() < eb.Order()
+		default:
+			return ea.Pattern() < eb.Pattern()
+		}
+	})
+	return r
+}
+
+// ByMnemonic returns the instructions ordered by mnemonic then encoding
+func (i *Instructions) ByMnemonic() []*Instruction {
+	r := i.ByEncoding()
+	sort.SliceStable(r, func(a, b int) bool {
+		return r[a].Op < r[b].Op
+	})
+	return r
+}
+
+// String returns the mnemonic with the optional S flag & condition code suffixes it supports, e.g. "ADC{S}{<c>}"
+func (i *Instruction) String() string {
+	s := i.Op
+	if i.Encoding.SetsFlags() {
+		s = s + "{S}"
+	}
+	if i.Encoding.Conditional() {
+		s = s + "{<c>}"
+	}
+	return s
+}
+
+// Format returns the instruction formatted for an index
+func (i *Instruction) Format() string {
+	return html.EscapeString(i.String())
+}
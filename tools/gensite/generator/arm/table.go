@@ -0,0 +1,50 @@
+package arm
+
+import (
+	"context"
+	"github.com/peter-mount/documentation/tools/gensite/generator"
+	"github.com/peter-mount/documentation/tools/gensite/util"
+	"strings"
+)
+
+// writeTable writes the instructions to the book's csv & Excel files
+func (s *ARM) writeTable(ctx context.Context) error {
+	book := generator.GetBook(ctx)
+	inst := s.Instructions(book).ByMnemonic()
+
+	return util.WithTable().
+		AsCSV(ctx, book.StaticPath("instructions.csv"), book.Modified()).
+		AsExcel(s.excel.Get(ctx, book.ID, book.Modified())).
+		Do(&util.Table{
+			Title: "arm",
+			Columns: []string{
+				"Instruction",
+				"Encoding",
+				"Width",
+				"Pattern",
+				"Format",
+				"Conditional",
+				"Sets Flags",
+				"Operands",
+				"Syntax",
+			},
+			RowCount: len(inst),
+			GetRow: func(r int) interface{} {
+				return inst[r]
+			},
+			Transform: func(i interface{}) []interface{} {
+				e := i.(*Instruction)
+				return []interface{}{
+					e.Op,
+					e.Form,
+					e.Encoding.Width,
+					e.Encoding.Pattern(),
+					e.Encoding.String(),
+					e.Encoding.Conditional(),
+					e.Encoding.SetsFlags(),
+					strings.Join(e.Encoding.Operands(), ", "),
+					strings.Join(e.Syntax, "\n"),
+				}
+			},
+		})
+}
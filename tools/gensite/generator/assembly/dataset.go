@@ -0,0 +1,70 @@
+package assembly
+
+import (
+	"context"
+	"github.com/peter-mount/documentation/tools/gensite/hugo"
+	"github.com/peter-mount/documentation/tools/gensite/util"
+	util2 "github.com/peter-mount/go-kernel/v2/util"
+)
+
+// DatasetEntry is an Opcode in the machine-readable dataset of an instruction set, written as json & yaml so
+// tools like disassemblers & emulator tests don't have to scrape the html
+type DatasetEntry struct {
+	Code          string            `json:"code" yaml:"code"`                                       // Opcode
+	Op            string            `json:"op" yaml:"op"`                                           // Mnemonic
+	Addressing    string            `json:"addressing,omitempty" yaml:"addressing,omitempty"`       // Addressing mode
+	Format        string            `json:"format,omitempty" yaml:"format,omitempty"`               // Binary format
+	Bytes         *DatasetValue     `json:"bytes,omitempty" yaml:"bytes,omitempty"`                 // Bytes
+	Cycles        *DatasetValue     `json:"cycles,omitempty" yaml:"cycles,omitempty"`               // Cycles
+	Compatibility map[string]bool   `json:"compatibility,omitempty" yaml:"compatibility,omitempty"` // Processors supporting it
+	Flags         map[string]string `json:"flags,omitempty" yaml:"flags,omitempty"`                 // Effect on each flag
+}
+
+// DatasetValue is an OpcodeType with the text of its notes
+type DatasetValue struct {
+	Value string   `json:"value" yaml:"value"`
+	Notes []string `json:"notes,omitempty" yaml:"notes,omitempty"`
+}
+
+// NewDatasetEntry returns the DatasetEntry of an Opcode
+func NewDatasetEntry(op *Opcode) *DatasetEntry {
+	e := &DatasetEntry{
+		Code:       op.Code,
+		Op:         op.Op,
+		Addressing: op.Addressing,
+		Format:     op.Format,
+		Bytes:      newDatasetValue(op.Bytes),
+		Cycles:     newDatasetValue(op.Cycles),
+		Flags:      op.Flags,
+	}
+	if op.Compatibility != nil && len(*op.Compatibility) > 0 {
+		e.Compatibility = *op.Compatibility
+	}
+	return e
+}
+
+func newDatasetValue(o *OpcodeType) *DatasetValue {
+	if o == nil || (o.Value == "" && len(o.Notes) == 0) {
+		return nil
+	}
+	v := &DatasetValue{Value: o.Value}
+	for _, n := range o.Notes {
+		v.Notes = append(v.Notes, n.Value)
+	}
+	return v
+}
+
+// WriteDataset writes the opcodes of a book as the book's opcodes.json & opcodes.yaml static files, in the order
+// of the iterator
+func WriteDataset(ctx context.Context, book *hugo.Book, iterator util2.Iterator[*Opcode]) error {
+	var entries []*DatasetEntry
+	for iterator.HasNext() {
+		entries = append(entries, NewDatasetEntry(iterator.Next()))
+	}
+
+	err := util.JsonFileHandler(entries).Write(ctx, book.StaticPath("opcodes.json"), book.Modified())
+	if err != nil {
+		return err
+	}
+	return util.YamlFileHandler(entries).Write(ctx, book.StaticPath("opcodes.yaml"), book.Modified())
+}
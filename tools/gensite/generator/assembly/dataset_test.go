@@ -0,0 +1,39 @@
+package assembly
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNewDatasetEntry(t *testing.T) {
+	i := NewInstructions()
+	notes := i.Notes()
+	notes.Add("65816: Add 1 cycle if m=0")
+	i.Extract("CMP", map[string]string{"z": "Set if zero"}, notes, map[interface{}]interface{}{
+		"code":          "C9",
+		"addressing":    "imm",
+		"compatibility": map[interface{}]interface{}{6502: true},
+		"bytes":         2,
+		"cycles":        map[interface{}]interface{}{"value": "2", "notes": []interface{}{1}},
+	})
+	i.Extract("CMP", map[string]string{"z": "Set if zero"}, notes, map[interface{}]interface{}{
+		"code":  "D2",
+		"flags": map[interface{}]interface{}{"c": "Set if greater"},
+	})
+	i.Normalise()
+
+	var got []string
+	i.Iterator().ForEach(func(op *Opcode) {
+		b, _ := json.Marshal(NewDatasetEntry(op))
+		got = append(got, string(b))
+	})
+
+	for n, want := range []string{
+		`{"code":"C9nn","op":"CMP","addressing":"imm","bytes":{"value":"2"},"cycles":{"value":"2","notes":["65816: Add 1 cycle if m=0"]},"compatibility":{"6502":true},"flags":{"z":"Set if zero"}}`,
+		`{"code":"D2","op":"CMP","flags":{"c":"Set if greater"}}`,
+	} {
+		if got[n] != want {
+			t.Errorf("got %s\nwant %s", got[n], want)
+		}
+	}
+}
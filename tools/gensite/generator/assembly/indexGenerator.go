@@ -1,13 +1,16 @@
 package assembly
 
 import (
+	"context"
 	"github.com/peter-mount/documentation/tools/gensite/hugo"
 	"github.com/peter-mount/documentation/tools/gensite/util"
+	"github.com/peter-mount/documentation/tools/gensite/util/autodoc"
 	util2 "github.com/peter-mount/go-kernel/v2/util"
 	"github.com/peter-mount/go-kernel/v2/util/strings"
 )
 
-// IndexGenerator generates the reference index pages for Assembly languages
+// IndexGenerator generates the reference index pages for Assembly languages.
+// It is also used for other indices, see Write.
 type IndexGenerator struct {
 	Prefix    string
 	Name      string
@@ -19,10 +22,29 @@ type IndexGenerator struct {
 	Body      func(slice strings.StringSlice, rowCount int, entry interface{}) strings.StringSlice
 }
 
-func (i *IndexGenerator) WriteFile(book *hugo.Book, iterator util2.Iterator[*Opcode]) error {
-	return util.ReferenceFileBuilder(
-		i.Title,
-		i.Desc,
+func (i *IndexGenerator) WriteFile(ctx context.Context, book *hugo.Book, iterator util2.Iterator[*Opcode]) error {
+	var entries []*Opcode
+	iterator.ForEach(func(op *Opcode) {
+		entries = append(entries, op)
+	})
+	return i.Write(ctx, book, Iterator(entries))
+}
+
+// Iterator returns an Iterator of the entries in a slice, to pass to Write
+func Iterator[T any](entries []T) util2.Iterator[interface{}] {
+	var r []interface{}
+	for _, e := range entries {
+		r = append(r, e)
+	}
+	return util2.NewIterator(r...)
+}
+
+// Write writes the index of any type of entry, the type Paginator & Body expect.
+// The book's reference section is given an index page if it does not have one.
+func (i *IndexGenerator) Write(ctx context.Context, book *hugo.Book, iterator util2.Iterator[interface{}]) error {
+	err := util.ReferenceFileBuilder(
+		book.I18n("reference_"+i.Name+"_title", i.Title),
+		book.I18n("reference_"+i.Name+"_desc", i.Desc),
 		"manual",
 		10,
 		book.Modified(),
@@ -47,7 +69,12 @@ func (i *IndexGenerator) WriteFile(book *hugo.Book, iterator util2.Iterator[*Opc
 			return slice, nil
 		}).
 		FileHandler().
-		Write(util.ReferenceFilename(book.ContentPath(), i.Name, "_index.html"), book.Modified())
+		Write(ctx, util.ReferenceFilename(book.ContentPath(), i.Name, book.PageName("_index.html")), book.Modified())
+	if err != nil {
+		return err
+	}
+
+	return autodoc.GenerateReferenceIndexFile(ctx, book.ContentPath("reference", book.PageName("_index.html")), book.Modified(), "reference", "")
 }
 
 func (i *IndexGenerator) startPage(rowCount int, slice strings.StringSlice) strings.StringSlice {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/peter-mount/documentation/tools/gensite/hugo"
 	"github.com/peter-mount/documentation/tools/gensite/util"
+	"github.com/peter-mount/documentation/tools/gensite/util/schema"
 	util2 "github.com/peter-mount/go-kernel/v2/util"
 	"sort"
 	"strconv"
@@ -20,6 +21,7 @@ type Instructions struct {
 	notes           *util.Notes                     // Notes, if any
 	ExtractFormat   func(format string, op *Opcode) // Extract format field (optional)
 	OpcodeFormatter func(op *Opcode) string         // Format Opcode
+	OpcodeBytes     func(code string) int           // Bytes of an opcode excluding operands (optional), defaults to 1
 }
 
 func NewInstructions() *Instructions {
@@ -106,7 +108,7 @@ func GetInstructions(ctx context.Context) *Instructions {
 }
 
 // Extract op code.
-func (i *Instructions) Extract(defaultOp string, n *util.Notes, e1 interface{}) {
+func (i *Instructions) Extract(defaultOp string, defaultFlags map[string]string, n *util.Notes, e1 interface{}) {
 	_ = util2.IfMap(e1, func(e map[interface{}]interface{}) error {
 
 		op := &Opcode{
@@ -116,6 +118,7 @@ func (i *Instructions) Extract(defaultOp string, n *util.Notes, e1 interface{})
 			Format:        util2.DecodeString(e["format"], ""),
 			Compatibility: util2.NewSortedMap[bool]().Decode(e["compatibility"]),
 			Colour:        util2.DecodeString(e["colour"], ""),
+			Flags:         decodeFlags(e["flags"], defaultFlags),
 		}
 
 		order, _ := strconv.ParseInt(op.Code, 16, 32)
@@ -133,8 +136,12 @@ func (i *Instructions) Extract(defaultOp string, n *util.Notes, e1 interface{})
 			op.Bytes = i.decodeOpType(n, e["bytes"])
 		}
 
-		if op.Bytes != nil && op.Bytes.Int() > 1 && !strings2.Contains(op.Code, "nn") {
-			op.Code = op.Code + strings2.Repeat("nn", op.Bytes.Int()-1)
+		opcodeBytes := 1
+		if i.OpcodeBytes != nil {
+			opcodeBytes = i.OpcodeBytes(op.Code)
+		}
+		if op.Bytes != nil && op.Bytes.Int() > opcodeBytes && !strings2.Contains(op.Code, "nn") {
+			op.Code = op.Code + strings2.Repeat("nn", op.Bytes.Int()-opcodeBytes)
 		}
 
 		op.Cycles = i.decodeOpType(n, e["cycles"])
@@ -147,21 +154,36 @@ func (i *Instructions) Extract(defaultOp string, n *util.Notes, e1 interface{})
 
 func (i *Instructions) ExtractFrontMatter(ctx context.Context, fm *hugo.FrontMatter) error {
 	if codes, exists := fm.Other["codes"]; exists {
-		var defaultOp string
-		if a, exists := fm.Other["op"]; exists {
-			defaultOp = a.(string)
-		}
+		util.GetProducer(ctx).AddSource(hugo.FilePath(ctx))
+
+		defaultOp := util2.DecodeString(fm.Other["op"], "")
+		defaultFlags := decodeFlags(fm.Other["flags"], nil)
 
 		notes := ctx.Value("notes").(*util.Notes)
 
 		_ = util2.ForEachInterface(codes, func(e1 interface{}) error {
-			i.Extract(defaultOp, notes, e1)
+			i.Extract(defaultOp, defaultFlags, notes, e1)
 			return nil
 		})
 	}
 	return nil
 }
 
+// decodeFlags decodes the flags front matter, the effect of an instruction on each flag, returning def if there is none
+func decodeFlags(e interface{}, def map[string]string) map[string]string {
+	flags := map[string]string{}
+	_ = util2.IfMap(e, func(m map[interface{}]interface{}) error {
+		for k, v := range m {
+			flags[util2.DecodeString(k, "")] = util2.DecodeString(v, "")
+		}
+		return nil
+	})
+	if len(flags) == 0 {
+		return def
+	}
+	return flags
+}
+
 func (i *Instructions) decodeOpType(n *util.Notes, e1 interface{}) *OpcodeType {
 	o := &OpcodeType{}
 
@@ -205,3 +227,36 @@ func (i *Instructions) Normalise() {
 func defaultOpcodeFormatter(op *Opcode) string {
 	return op.Op
 }
+
+// Schema returns the schema of the codes front matter read by ExtractFrontMatter, including the fields only the
+// theme's templates use
+func Schema() *schema.Schema {
+	// bytes & cycles are either a value or a value with the ids of notes about it
+	opType := func() *schema.Schema {
+		return schema.OneOf(
+			schema.String(),
+			schema.Map().
+				Field("value", schema.String()).
+				Field("notes", schema.List(schema.Int())))
+	}
+
+	return schema.List(schema.Map().
+		Field("code", schema.String()).
+		Field("op", schema.String()).
+		Field("addressing", schema.String()).
+		Field("format", schema.String()).
+		Field("compatibility", schema.MapOf(schema.Bool())).
+		Field("colour", schema.String()).
+		Field("size", opType()).
+		Field("bytes", opType()).
+		Field("cycles", opType()).
+		Field("match", schema.String()).
+		Field("action", schema.String()).
+		Field("alt", schema.String()).
+		Field("altright", schema.String()).
+		Field("syntax", schema.List(schema.String())).
+		Field("datasize", schema.OneOf(schema.String(), schema.List(schema.String()))).
+		Field("src", schema.String()).
+		Field("dest", schema.String()).
+		Field("flags", schema.MapOf(schema.String())))
+}
@@ -15,4 +15,5 @@ type Opcode struct {
 	Cycles        *OpcodeType           // Cycles opcode uses (optional)
 	Notes         []int                 // Notes about opcode
 	Colour        string                // Colour used in rendering (optional)
+	Flags         map[string]string     // Effect on each flag (optional), defaults to the flags of the page
 }
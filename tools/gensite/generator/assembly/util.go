@@ -1,23 +1,10 @@
 package assembly
 
 import (
-	"context"
-	"github.com/peter-mount/documentation/tools/gensite"
-	"github.com/peter-mount/documentation/tools/gensite/generator"
-	"github.com/peter-mount/go-kernel/v2/util/task"
 	"strconv"
 	"strings"
 )
 
-func DelayOpTask(t task.Task) task.Task {
-	return func(ctx context.Context) error {
-		task.GetQueue(ctx).AddPriorityTask(tools.PriorityIndices,
-			task.Of(t).
-				WithContext(ctx, generator.BookKey))
-		return nil
-	}
-}
-
 func DecodeOpcode(s string) int64 {
 	s = strings.ReplaceAll(s, "nn", "00")
 	for len(s) < 8 {
@@ -8,22 +8,31 @@ import (
 	"github.com/peter-mount/documentation/tools/gensite/util/autodoc"
 	"github.com/peter-mount/documentation/tools/gensite/util/autodoc/asm"
 	"github.com/peter-mount/go-kernel/v2/util/task"
-	"os"
 	"path"
+	"sync"
 )
 
-// Api manages the collation of API calls in a book
+// Api manages the collation of API calls in a book. It is safe for concurrent use.
 type Api struct {
-	m         map[string]*ApiEntry
-	api       []*ApiEntry
-	extracted bool
+	mutex sync.Mutex
+	stats *generator.Stats // Stats to time the tasks generating the api files
+	m     map[string]*ApiEntry
+	api   []*ApiEntry
 }
 
-func NewApi() *Api {
-	return &Api{m: make(map[string]*ApiEntry)}
+func NewApi(stats *generator.Stats) *Api {
+	return &Api{stats: stats, m: make(map[string]*ApiEntry)}
+}
+
+// timed returns a task timed against the Producer in a context
+func (a *Api) timed(ctx context.Context, t task.Task) task.Task {
+	return a.stats.Timed(util.GetProducer(ctx), generator.PhaseWrite, t)
 }
 
 func (a *Api) Add(e *ApiEntry) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
 	if ee, exists := a.m[e.Name]; exists {
 		return fmt.Errorf("entry %q (%q) already exists with address %q", e.Name, e.Addr, ee.Addr)
 	}
@@ -34,8 +43,22 @@ func (a *Api) Add(e *ApiEntry) error {
 	return nil
 }
 
+// Size returns the number of entries
+func (a *Api) Size() int {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return len(a.api)
+}
+
+// entries returns a snapshot of the entries in their current order
+func (a *Api) entries() []*ApiEntry {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return append([]*ApiEntry(nil), a.api...)
+}
+
 func (a *Api) ForEach(h ApiEntryHandler) error {
-	for _, e := range a.api {
+	for _, e := range a.entries() {
 		err := h(e)
 		if err != nil {
 			return err
@@ -46,14 +69,14 @@ func (a *Api) ForEach(h ApiEntryHandler) error {
 
 // generateSource is a Task for generating source code
 func (a *Api) generateSource(ctx context.Context) error {
-	if len(a.api) > 0 {
+	if a.Size() > 0 {
 		book := generator.GetBook(ctx)
 
 		dirName := book.ContentPath("reference/include")
 		fileName := "api"
 
 		task.GetQueue(ctx).
-			AddTask(task.Of().
+			AddTask(a.timed(ctx, task.Of().
 				Then(a.SortByAddr).
 				Then(autodoc.For(dirName, fileName, book.Modified(), ctx).
 					Using(asm.BeebAsm).
@@ -61,7 +84,7 @@ func (a *Api) generateSource(ctx context.Context) error {
 					InvokeTopic("API", buildHeaderFile).
 					Invoke(a.autodocHandler()).
 					Do).
-				WithContext(ctx, generator.BookKey, autodoc.ResourceManagerKey))
+				WithContext(ctx, generator.BookKey, autodoc.ResourceManagerKey, util.ProducerKey)))
 
 	}
 
@@ -73,14 +96,10 @@ func (a *Api) generateResource(ctx context.Context) error {
 
 	dirName := book.ContentPath("reference")
 
-	if err := os.MkdirAll(dirName, 0755); err != nil {
-		return err
-	}
-
 	task.GetQueue(ctx).
-		AddTask(
+		AddTask(a.timed(ctx,
 			autodoc.GenerateReferenceIndices(path.Join(dirName, "_index.html"), book.Modified()).
-				WithContext(ctx, generator.BookKey, autodoc.ResourceManagerKey))
+				WithContext(ctx, generator.BookKey, autodoc.ResourceManagerKey, util.ProducerKey)))
 	return nil
 }
 
@@ -95,21 +114,21 @@ func (a *Api) autodocHandler() autodoc.Handler {
 
 // generateIndex generates the API indices
 func (a *Api) generateIndex(ctx context.Context) error {
-	if len(a.api) > 0 {
+	if a.Size() > 0 {
 
 		task.GetQueue(ctx).
-			AddTask(task.Of().
+			AddTask(a.timed(ctx, task.Of().
 				Then(a.SortByAddr).
 				Then(a.generateIndexFile).
 				WithValue("filename", "api").
 				WithValue("title", "API by Address").
-				WithContext(ctx, generator.BookKey, autodoc.ResourceManagerKey)).
-			AddTask(task.Of().
+				WithContext(ctx, generator.BookKey, autodoc.ResourceManagerKey, util.ProducerKey))).
+			AddTask(a.timed(ctx, task.Of().
 				Then(a.SortByName).
 				Then(a.generateIndexFile).
 				WithValue("filename", "apiname").
 				WithValue("title", "API by Name").
-				WithContext(ctx, generator.BookKey, autodoc.ResourceManagerKey))
+				WithContext(ctx, generator.BookKey, autodoc.ResourceManagerKey, util.ProducerKey)))
 
 	}
 	return nil
@@ -123,7 +142,7 @@ func (a *Api) generateIndexFile(ctx context.Context) error {
 	title := ctx.Value("title").(string)
 
 	r := Output{Nometa: true}
-	for _, o := range a.api {
+	for _, o := range a.entries() {
 		r.Api = append(r.Api, o.params)
 	}
 
@@ -131,5 +150,5 @@ func (a *Api) generateIndexFile(ctx context.Context) error {
 		Yaml(r).
 		WrapAsFrontMatter().
 		FileHandler().
-		Write(util.ReferenceFilename(book.ContentPath(), fileName, "_index.html"), book.Modified())
+		Write(ctx, util.ReferenceFilename(book.ContentPath(), fileName, "_index.html"), book.Modified())
 }
@@ -20,6 +20,8 @@ type ApiEntry struct {
 type ApiEntryHandler func(*ApiEntry) error
 
 func (a *Api) SortByName(_ context.Context) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
 	sort.SliceStable(a.api, func(i, j int) bool {
 		return strings.ToLower(a.api[i].Name) < strings.ToLower(a.api[j].Name)
 	})
@@ -27,6 +29,8 @@ func (a *Api) SortByName(_ context.Context) error {
 }
 
 func (a *Api) SortByAddr(_ context.Context) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
 	sort.SliceStable(a.api, func(i, j int) bool {
 		return a.api[i].call < a.api[j].call
 	})
@@ -4,66 +4,124 @@ import (
 	"context"
 	"github.com/peter-mount/documentation/tools/gensite"
 	"github.com/peter-mount/documentation/tools/gensite/generator"
+	"github.com/peter-mount/documentation/tools/gensite/hugo"
+	util2 "github.com/peter-mount/documentation/tools/gensite/util"
 	"github.com/peter-mount/documentation/tools/gensite/util/autodoc"
+	"github.com/peter-mount/documentation/tools/gensite/util/schema"
 	"github.com/peter-mount/go-kernel/v2/util"
 	"github.com/peter-mount/go-kernel/v2/util/task"
 )
 
+const (
+	ApiStage = "autodoc.api" // API & memory map entries have been extracted from a book
+)
+
 type Autodoc struct {
 	generator       *generator.Generator     `kernel:"inject"` // Generator
+	stats           *generator.Stats         `kernel:"inject"` // Stats
 	resourceManager *autodoc.ResourceManager `kernel:"inject"` // ResourceManager
+	index           *hugo.ContentIndex       `kernel:"inject"` // ContentIndex
 	extracted       util.Set[string]         // Set of book ID's so that we run once per book
-	headers         map[string]*Headers      // Headers per book
-	apis            map[string]*Api          // Apis per book
+	headers         util.Map[*Headers]       // Headers per book
+	apis            util.Map[*Api]           // Apis per book
 }
 
 func (s *Autodoc) Start() error {
-	s.extracted = util.NewHashSet[string]()
-	s.headers = make(map[string]*Headers)
-	s.apis = make(map[string]*Api)
+	s.extracted = util.NewSyncSet[string]()
+	s.headers = util.NewSyncMap[*Headers]()
+	s.apis = util.NewSyncMap[*Api]()
 
 	s.generator.
 		Register("autodoc", task.Of(s.extract).
-			WithValue(autodoc.ResourceManagerKey, s.resourceManager))
+			WithValue(autodoc.ResourceManagerKey, s.resourceManager),
+			generator.Provides(ApiStage),
+			generator.Validates("api", apiSchema()),
+			generator.Validates("memorymap", memoryMapSchema())).
+		OnReset(s.reset)
 
 	return nil
 }
 
+// paramsSchema is the schema of FunctionParams. The interrupt flag, i, is documented but not used.
+func paramsSchema() *schema.Schema {
+	return schema.Map().
+		Field("a", schema.String()).
+		Field("x", schema.String()).
+		Field("y", schema.String()).
+		Field("c", schema.String()).
+		Field("i", schema.String())
+}
+
+func apiSchema() *schema.Schema {
+	return schema.List(schema.Map().
+		Field("name", schema.String().Unique("api name")).
+		Field("addr", schema.Hex()).
+		Field("indirect", schema.Hex()).
+		Field("title", schema.String()).
+		Field("alt", schema.String()).
+		Field("entry", paramsSchema()).
+		Field("exit", paramsSchema()))
+}
+
+// memoryMapSchema is the schema of the memorymap front matter, including the fields only the theme's templates use
+func memoryMapSchema() *schema.Schema {
+	return schema.List(schema.Map().
+		Field("name", schema.String()).
+		Field("address", schema.Hex()).
+		Field("value", schema.String()).
+		Field("desc", schema.String()).
+		Field("length", schema.Int()).
+		Field("default", schema.String()).
+		Field("note", schema.String()).
+		Field("offset", schema.OneOf(schema.Bool(), schema.String())).
+		Field("format", schema.List(schema.OneOf(
+			schema.String(),
+			schema.Map().
+				Field("label", schema.String()).
+				Field("span", schema.Int())))).
+		Field("val", schema.MapOf(schema.String())))
+}
+
+// reset discards the headers, api's & resources extracted from a book so that it can be regenerated
+func (s *Autodoc) reset(book *hugo.Book) error {
+	s.extracted.Remove(book.ID)
+	s.headers.Remove(book.ID)
+	s.apis.Remove(book.ID)
+	s.resourceManager.Reset(book.ContentPath())
+	return nil
+}
+
 func (s *Autodoc) getHeaders(ctx context.Context) *Headers {
 	book := generator.GetBook(ctx)
 
-	if h, exists := s.headers[book.ID]; exists {
-		return h
-	}
-
-	h := NewHeaders()
-	s.headers[book.ID] = h
+	return s.headers.ComputeIfAbsent(book.ID, func(_ string) *Headers {
+		h := NewHeaders(s.stats)
 
-	task.GetQueue(ctx).
-		AddPriorityTask(tools.PriorityApi, task.Of(h.task).
-			WithValue(generator.BookKey, book).
-			WithValue(autodoc.ResourceManagerKey, s.resourceManager))
+		task.GetQueue(ctx).
+			AddPriorityTask(tools.PriorityApi, s.stats.Timed(util2.GetProducer(ctx), generator.PhaseWrite, task.Of(h.task).
+				WithValue(generator.BookKey, book).
+				WithValue(autodoc.ResourceManagerKey, s.resourceManager).
+				WithContext(ctx, util2.ProducerKey)))
 
-	return h
+		return h
+	})
 }
 
 func (s *Autodoc) GetApi(ctx context.Context) *Api {
 	book := generator.GetBook(ctx)
 
-	if a, exists := s.apis[book.ID]; exists {
-		return a
-	}
-
-	a := NewApi()
-	s.apis[book.ID] = a
+	return s.apis.ComputeIfAbsent(book.ID, func(_ string) *Api {
+		a := NewApi(s.stats)
 
-	task.GetQueue(ctx).
-		AddPriorityTask(tools.PriorityApi, task.Of().
-			Then(a.generateResource).
-			Then(a.generateSource).
-			Then(a.generateIndex).
-			WithValue(generator.BookKey, book).
-			WithValue(autodoc.ResourceManagerKey, s.resourceManager))
+		task.GetQueue(ctx).
+			AddPriorityTask(tools.PriorityApi, s.stats.Timed(util2.GetProducer(ctx), generator.PhaseWrite, task.Of().
+				Then(a.generateResource).
+				Then(a.generateSource).
+				Then(a.generateIndex).
+				WithValue(generator.BookKey, book).
+				WithValue(autodoc.ResourceManagerKey, s.resourceManager).
+				WithContext(ctx, util2.ProducerKey)))
 
-	return a
+		return a
+	})
 }
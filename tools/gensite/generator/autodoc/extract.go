@@ -7,31 +7,21 @@ import (
 	"github.com/peter-mount/documentation/tools/gensite/hugo"
 	"github.com/peter-mount/go-kernel/v2/log"
 	"github.com/peter-mount/go-kernel/v2/util"
-	"github.com/peter-mount/go-kernel/v2/util/walk"
 )
 
 func (s *Autodoc) extract(ctx context.Context) error {
 	book := generator.GetBook(ctx)
 
 	// Only run once per Book ID
-	if s.extracted.Contains(book.ID) {
+	if !s.extracted.Add(book.ID) {
 		return nil
 	}
 
-	// Prevent us running again for this Book
-	s.extracted.Add(book.ID)
-
 	log.Printf("Scanning %s for autodocs", book.ID)
 
-	return walk.NewPathWalker().
-		IsFile().
-		PathNotContain("/reference/").
-		PathHasSuffix(".html").
-		Then(hugo.FrontMatterActionOf().
-			OtherExists("api", s.extractApi).
-			OtherExists("memorymap", s.extractMemoryMap).
-			Walk(ctx)).
-		Walk(book.ContentPath())
+	return s.index.Walk(ctx, book, hugo.FrontMatterActionOf().
+		OtherExists("api", s.extractApi).
+		OtherExists("memorymap", s.extractMemoryMap))
 }
 
 func (s *Autodoc) extractMemoryMap(ctx context.Context, fm *hugo.FrontMatter) error {
@@ -88,9 +78,7 @@ func (s *Autodoc) extractApi(ctx context.Context, fm *hugo.FrontMatter) error {
 				return err
 			}
 
-			api.Add(v)
-
-			return nil
+			return api.Add(v)
 		})
 	})
 }
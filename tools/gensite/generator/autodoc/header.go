@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"github.com/microcosm-cc/bluemonday"
 	"github.com/peter-mount/documentation/tools/gensite/generator"
+	"github.com/peter-mount/documentation/tools/gensite/util"
 	"github.com/peter-mount/documentation/tools/gensite/util/autodoc"
 	"github.com/peter-mount/documentation/tools/gensite/util/autodoc/asm"
 	"github.com/peter-mount/go-kernel/v2/util/task"
 	"strings"
+	"sync"
 )
 
 // Header is a constant value taken from source, e.g. memory map
@@ -37,18 +39,24 @@ func (h HeaderHandler) Then(b HeaderHandler) HeaderHandler {
 	}
 }
 
+// Headers is a collection of Header's in the order they were added. It is safe for concurrent use.
 type Headers struct {
-	m map[string]*Header // Map of headers
-	a []*Header          // Slice of headers for ordering
+	mutex sync.Mutex
+	stats *generator.Stats   // Stats to time the task generating the header file
+	m     map[string]*Header // Map of headers
+	a     []*Header          // Slice of headers for ordering
 }
 
-func NewHeaders() *Headers {
-	return &Headers{m: make(map[string]*Header)}
+func NewHeaders(stats *generator.Stats) *Headers {
+	return &Headers{stats: stats, m: make(map[string]*Header)}
 }
 
 var hdrBreak = []string{"<br/>", "<br>", "\n", "\\n"}
 
 func (h *Headers) Add(header *Header) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
 	if header.Label != "" {
 		if e, exists := h.m[header.Label]; exists {
 			return fmt.Errorf("entry %q already exists with %q ; %q", e.Label, e.Value, e.Comment)
@@ -62,7 +70,11 @@ func (h *Headers) Add(header *Header) error {
 }
 
 func (h *Headers) ForEach(f HeaderHandler) error {
-	for _, e := range h.a {
+	h.mutex.Lock()
+	a := append([]*Header(nil), h.a...)
+	h.mutex.Unlock()
+
+	for _, e := range a {
 		err := f(e)
 		if err != nil {
 			return err
@@ -79,14 +91,14 @@ func (h *Headers) task(ctx context.Context) error {
 	fileName := "headers"
 
 	task.GetQueue(ctx).
-		AddTask(task.Of().
+		AddTask(h.stats.Timed(util.GetProducer(ctx), generator.PhaseWrite, task.Of().
 			Then(autodoc.For(dirName, fileName, book.Modified(), ctx).
 				Using(asm.BeebAsm).
 				Using(asm.ZAsm).
 				InvokeTopic("Headers", buildHeaderFile).
 				Invoke(h.AutodocHandler()).
 				Do).
-			WithContext(ctx, generator.BookKey, autodoc.ResourceManagerKey))
+			WithContext(ctx, generator.BookKey, autodoc.ResourceManagerKey, util.ProducerKey)))
 
 	return nil
 }
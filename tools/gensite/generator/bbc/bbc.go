@@ -4,9 +4,14 @@ import (
 	"context"
 	"github.com/peter-mount/documentation/tools/gensite/generator"
 	"github.com/peter-mount/documentation/tools/gensite/hugo"
+	"github.com/peter-mount/documentation/tools/gensite/util/schema"
 	"github.com/peter-mount/go-kernel/v2/log"
+	"github.com/peter-mount/go-kernel/v2/util"
 	"github.com/peter-mount/go-kernel/v2/util/task"
-	"github.com/peter-mount/go-kernel/v2/util/walk"
+)
+
+const (
+	ApiStage = "bbc.api" // OSBYTE & OSWORD calls have been extracted
 )
 
 // BBC generates the reference pages in the BBC book.
@@ -14,9 +19,15 @@ import (
 type BBC struct {
 	generator *generator.Generator `kernel:"inject"` // Generator
 	excel     *generator.Excel     `kernel:"inject"` // Excel
-	extracted bool                 // True once extract() has run
-	osbyte    []*Osbyte            // OSBYTE calls
-	osword    []*Osword            // OSWORD calls
+	index     *hugo.ContentIndex   `kernel:"inject"` // ContentIndex
+	extracted util.Set[string]     // Set of book ID's so that we run once per book
+	calls     util.Map[*Calls]     // Calls extracted per book
+}
+
+// Calls holds the calls extracted from a book
+type Calls struct {
+	osbyte []*Osbyte // OSBYTE calls
+	osword []*Osword // OSWORD calls
 }
 
 // Output is used for generating the index pages front matter
@@ -28,33 +39,99 @@ type Output struct {
 }
 
 func (b *BBC) Start() error {
+	b.extracted = util.NewSyncSet[string]()
+	b.calls = util.NewSyncMap[*Calls]()
+
 	b.generator.
+		Register("bbcApi",
+			task.Of(b.extract),
+			generator.Provides(ApiStage),
+			generator.Translated(),
+			generator.Validates("osbyte", osbyteSchema()),
+			generator.Validates("osword", oswordSchema())).
 		Register("bbcOsbyteIndex",
-			task.Of().
-				RunOnce(&b.extracted, b.extract).
-				Then(b.writeOsbyteIndex).
-				Then(b.writeOsbyteTable)).
+			task.Of(b.writeOsbyteIndex),
+			generator.Needs(ApiStage),
+			generator.Translated()).
+		Register("bbcOsbyteTable",
+			task.Of(b.writeOsbyteTable),
+			generator.Needs(ApiStage)).
 		Register("bbcOswordIndex",
-			task.Of().
-				RunOnce(&b.extracted, b.extract).
-				Then(b.writeOswordIndex).
-				Then(b.writeOswordTable))
+			task.Of(b.writeOswordIndex),
+			generator.Needs(ApiStage),
+			generator.Translated()).
+		Register("bbcOswordTable",
+			task.Of(b.writeOswordTable),
+			generator.Needs(ApiStage)).
+		OnReset(b.reset)
+
+	return nil
+}
+
+// paramsSchema is the schema of FunctionParams
+func paramsSchema() *schema.Schema {
+	return schema.Map().
+		Field("a", schema.String()).
+		Field("x", schema.String()).
+		Field("y", schema.String()).
+		Field("c", schema.String())
+}
 
+// compatibilitySchema is the schema of Compatibility
+func compatibilitySchema() *schema.Schema {
+	return schema.Map().
+		Field("bbc", schema.Bool()).
+		Field("master", schema.Bool()).
+		Field("electron", schema.Bool()).
+		Field("other", schema.String())
+}
+
+func osbyteSchema() *schema.Schema {
+	return schema.List(schema.Map().
+		Field("int", schema.Int().Unique("osbyte")).
+		Field("hex", schema.Hex()).
+		Field("title", schema.String()).
+		Field("index", schema.String()).
+		Field("entry", paramsSchema()).
+		Field("exit", paramsSchema()).
+		Field("compatibility", compatibilitySchema()))
+}
+
+func oswordSchema() *schema.Schema {
+	return schema.List(schema.Map().
+		Field("int", schema.Int().Unique("osword")).
+		Field("hex", schema.Hex()).
+		Field("title", schema.String()).
+		Field("entry", paramsSchema()).
+		Field("exit", paramsSchema()).
+		Field("compatibility", compatibilitySchema()))
+}
+
+// reset discards the calls extracted from a book so that it can be regenerated
+func (b *BBC) reset(book *hugo.Book) error {
+	b.extracted.Remove(book.ID)
+	b.calls.Remove(book.ID)
 	return nil
 }
 
+// Calls returns the Calls extracted for a Book
+func (b *BBC) Calls(book *hugo.Book) *Calls {
+	return b.calls.ComputeIfAbsent(book.ID, func(_ string) *Calls {
+		return &Calls{}
+	})
+}
+
 func (b *BBC) extract(ctx context.Context) error {
 	book := generator.GetBook(ctx)
 
-	log.Println("Scanning BBC API")
-
-	return walk.NewPathWalker().
-		Then(hugo.FrontMatterActionOf().
-			OtherExists("osbyte", b.extractOsbyte).
-			OtherExists("osword", b.extractOsword).
-			Walk(ctx)).
-		PathNotContain("/reference/").
-		PathHasSuffix(".html").
-		IsFile().
-		Walk(book.ContentPath())
+	// Only run once per Book ID
+	if !b.extracted.Add(book.ID) {
+		return nil
+	}
+
+	log.Printf("Scanning %s for BBC API", book.ID)
+
+	return b.index.Walk(ctx, book, hugo.FrontMatterActionOf().
+		OtherExists("osbyte", b.extractOsbyte).
+		OtherExists("osword", b.extractOsword))
 }
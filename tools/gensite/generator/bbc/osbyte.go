@@ -54,6 +54,7 @@ func (c *Compatibility) decode(e interface{}) error {
 }
 
 func (b *BBC) extractOsbyte(ctx context.Context, _ *hugo.FrontMatter) error {
+	calls := b.Calls(generator.GetBook(ctx))
 	return util2.ForEachInterface(ctx.Value("other"), func(e interface{}) error {
 		return util2.IfMap(e, func(m map[interface{}]interface{}) error {
 			if v, ok := util2.DecodeInt(m["int"], 0); ok {
@@ -84,7 +85,7 @@ func (b *BBC) extractOsbyte(ctx context.Context, _ *hugo.FrontMatter) error {
 					o.Entry.A = "&" + o.Hex
 				}
 
-				b.osbyte = append(b.osbyte, o)
+				calls.osbyte = append(calls.osbyte, o)
 			}
 			return nil
 		})
@@ -93,19 +94,20 @@ func (b *BBC) extractOsbyte(ctx context.Context, _ *hugo.FrontMatter) error {
 
 func (b *BBC) writeOsbyteIndex(ctx context.Context) error {
 	book := generator.GetBook(ctx)
+	calls := b.Calls(book)
 
-	sort.SliceStable(b.osbyte, func(i, j int) bool {
-		return b.osbyte[i].Call < b.osbyte[j].Call
+	sort.SliceStable(calls.osbyte, func(i, j int) bool {
+		return calls.osbyte[i].Call < calls.osbyte[j].Call
 	})
 
 	r := Output{Nometa: true}
-	for _, o := range b.osbyte {
+	for _, o := range calls.osbyte {
 		r.Osbyte = append(r.Osbyte, o.params)
 	}
 
 	return util.ReferenceFileBuilder(
-		"OSByte calls",
-		"OSByte &FFF4 calls",
+		book.I18n("reference_osbyte_title", "OSByte calls"),
+		book.I18n("reference_osbyte_desc", "OSByte &FFF4 calls"),
 		"manual",
 		10,
 		book.Modified(),
@@ -113,15 +115,16 @@ func (b *BBC) writeOsbyteIndex(ctx context.Context) error {
 		Yaml(r).
 		WrapAsFrontMatter().
 		FileHandler().
-		Write(util.ReferenceFilename(book.ContentPath(), "osbyte", "_index.html"), book.Modified())
+		Write(ctx, util.ReferenceFilename(book.ContentPath(), "osbyte", book.PageName("_index.html")), book.Modified())
 }
 
 func (b *BBC) writeOsbyteTable(ctx context.Context) error {
 	book := generator.GetBook(ctx)
+	calls := b.Calls(book)
 
 	return util.WithTable().
-		AsCSV(book.StaticPath("osbyte.csv"), book.Modified()).
-		AsExcel(b.excel.Get(book.ID, book.Modified())).
+		AsCSV(ctx, book.StaticPath("osbyte.csv"), book.Modified()).
+		AsExcel(b.excel.Get(ctx, book.ID, book.Modified())).
 		Do(&util.Table{
 			Title: "osbyte",
 			Columns: []string{
@@ -140,9 +143,9 @@ func (b *BBC) writeOsbyteTable(ctx context.Context) error {
 				"Electron",
 				"Other",
 			},
-			RowCount: len(b.osbyte),
+			RowCount: len(calls.osbyte),
 			GetRow: func(r int) interface{} {
-				return b.osbyte[r]
+				return calls.osbyte[r]
 			},
 			Transform: func(i interface{}) []interface{} {
 				o := i.(*Osbyte)
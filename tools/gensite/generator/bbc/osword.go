@@ -19,6 +19,7 @@ type Osword struct {
 }
 
 func (b *BBC) extractOsword(ctx context.Context, _ *hugo.FrontMatter) error {
+	calls := b.Calls(generator.GetBook(ctx))
 	return util2.ForEachInterface(ctx.Value("other"), func(e interface{}) error {
 		return util2.IfMap(e, func(m map[interface{}]interface{}) error {
 			if _, ok := util2.DecodeInt(m["int"], 0); ok {
@@ -37,7 +38,7 @@ func (b *BBC) extractOsword(ctx context.Context, _ *hugo.FrontMatter) error {
 					return err
 				}
 
-				b.osword = append(b.osword, o)
+				calls.osword = append(calls.osword, o)
 			}
 			return nil
 		})
@@ -46,19 +47,20 @@ func (b *BBC) extractOsword(ctx context.Context, _ *hugo.FrontMatter) error {
 
 func (b *BBC) writeOswordIndex(ctx context.Context) error {
 	book := generator.GetBook(ctx)
+	calls := b.Calls(book)
 
-	sort.SliceStable(b.osword, func(i, j int) bool {
-		return b.osword[i].Call < b.osword[j].Call
+	sort.SliceStable(calls.osword, func(i, j int) bool {
+		return calls.osword[i].Call < calls.osword[j].Call
 	})
 
 	r := Output{Nometa: true}
-	for _, o := range b.osword {
+	for _, o := range calls.osword {
 		r.Osword = append(r.Osword, o.params)
 	}
 
 	return util.ReferenceFileBuilder(
-		"OSWord calls",
-		"OSWord &FFF1 calls",
+		book.I18n("reference_osword_title", "OSWord calls"),
+		book.I18n("reference_osword_desc", "OSWord &FFF1 calls"),
 		"manual",
 		10,
 		book.Modified(),
@@ -66,15 +68,16 @@ func (b *BBC) writeOswordIndex(ctx context.Context) error {
 		Yaml(r).
 		WrapAsFrontMatter().
 		FileHandler().
-		Write(util.ReferenceFilename(book.ContentPath(), "osword", "_index.html"), book.Modified())
+		Write(ctx, util.ReferenceFilename(book.ContentPath(), "osword", book.PageName("_index.html")), book.Modified())
 }
 
 func (b *BBC) writeOswordTable(ctx context.Context) error {
 	book := generator.GetBook(ctx)
+	calls := b.Calls(book)
 
 	return util.WithTable().
-		AsCSV(book.StaticPath("osword.csv"), book.Modified()).
-		AsExcel(b.excel.Get(book.ID, book.Modified())).
+		AsCSV(ctx, book.StaticPath("osword.csv"), book.Modified()).
+		AsExcel(b.excel.Get(ctx, book.ID, book.Modified())).
 		Do(&util.Table{
 			Title: "osword",
 			Columns: []string{
@@ -90,9 +93,9 @@ func (b *BBC) writeOswordTable(ctx context.Context) error {
 				"Electron",
 				"Other",
 			},
-			RowCount: len(b.osword),
+			RowCount: len(calls.osword),
 			GetRow: func(r int) interface{} {
-				return b.osword[r]
+				return calls.osword[r]
 			},
 			Transform: func(i interface{}) []interface{} {
 				o := i.(*Osword)
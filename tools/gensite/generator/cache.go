@@ -0,0 +1,189 @@
+package generator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/peter-mount/documentation/tools/gensite"
+	"github.com/peter-mount/documentation/tools/gensite/hugo"
+	"github.com/peter-mount/documentation/tools/gensite/util"
+	"github.com/peter-mount/go-kernel/v2/log"
+	"github.com/peter-mount/go-kernel/v2/util/task"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	CacheDir = ".gensite-cache" // Directory holding the build cache
+)
+
+// Cache is a persistent build cache which allows books whose content has not changed since the last run to be skipped.
+//
+// For each book it records the hash of every page & the Manifest entries of the files generated from them.
+// A book is unchanged if its pages, the list of generators, the gensite binary & the pages hugo builds, see
+// hugo.BuildOptions, are the same as the last run, and the files generated from them still exist.
+//
+// The cache is saved once everything has been generated, so when hugo is run as a server it is only saved by -watch.
+type Cache struct {
+	dryRun    *util.DryRun       `kernel:"inject"`                                                      // DryRun
+	manifest  *Manifest          `kernel:"inject"`                                                      // Manifest
+	hugo      *hugo.Hugo         `kernel:"inject"`                                                      // Hugo
+	index     *hugo.ContentIndex `kernel:"inject"`                                                      // ContentIndex
+	worker    task.Queue         `kernel:"worker"`                                                      // Worker queue
+	force     *bool              `kernel:"flag,force,Ignore the build cache and regenerate every book"` // Ignore the cache
+	mutex     sync.Mutex
+	key       string              // Hash of the gensite binary, "" if not available
+	keyOnce   sync.Once           // Guards key
+	generated map[*hugo.Book]bool // Books generated during this run
+}
+
+// cacheEntry is the cache record for a single book
+type cacheEntry struct {
+	Key      string            `json:"key"`      // Hash of the gensite binary
+	Generate []string          `json:"generate"` // Generators run on the book
+	Build    hugo.BuildOptions `json:"build"`    // Pages hugo builds
+	Until    time.Time         `json:"until"`    // When the pages hugo builds change as a page is published or expires
+	Pages    map[string]string `json:"pages"`    // Hash of each page in the book
+	Outputs  []*ManifestEntry  `json:"outputs"`  // Files generated for the book
+}
+
+func (c *Cache) Start() error {
+	c.generated = make(map[*hugo.Book]bool)
+
+	// Save once all generation has completed
+	c.worker.AddPriorityTask(tools.PriorityCache, c.save)
+	return nil
+}
+
+// binaryKey returns the hash of the running binary, so that any change to the generators invalidates the cache.
+func (c *Cache) binaryKey() string {
+	c.keyOnce.Do(func() {
+		exe, err := os.Executable()
+		if err == nil {
+			var f *os.File
+			f, err = os.Open(exe)
+			if err == nil {
+				defer f.Close()
+				h := sha256.New()
+				if _, err = io.Copy(h, f); err == nil {
+					c.key = hex.EncodeToString(h.Sum(nil))
+				}
+			}
+		}
+		if err != nil {
+			log.Printf("Build cache disabled: %v", err)
+		}
+	})
+	return c.key
+}
+
+func cacheFileName(book *hugo.Book) string {
+	return path.Join(CacheDir, book.ID+".json")
+}
+
+// Unchanged returns true if the book has not changed since it was last generated.
+// If unchanged then the files generated for the book are added to the Manifest.
+func (c *Cache) Unchanged(book *hugo.Book) bool {
+	if *c.force || c.binaryKey() == "" {
+		return false
+	}
+
+	buf, err := os.ReadFile(cacheFileName(book))
+	if err != nil {
+		return false
+	}
+
+	entry := cacheEntry{}
+	if err = json.Unmarshal(buf, &entry); err != nil {
+		log.Printf("book %s invalid cache entry: %v", book.ID, err)
+		return false
+	}
+
+	if entry.Key != c.binaryKey() || strings.Join(entry.Generate, ",") != strings.Join(book.Generate, ",") {
+		return false
+	}
+
+	if entry.Build != c.hugo.BuildOptions() || (!entry.Until.IsZero() && !time.Now().Before(entry.Until)) {
+		return false
+	}
+
+	pages, err := book.Pages()
+	if err != nil {
+		log.Printf("book %s: %v", book.ID, err)
+		return false
+	}
+	if len(pages) != len(entry.Pages) {
+		return false
+	}
+	for k, v := range pages {
+		if entry.Pages[k] != v {
+			log.Printf("book %s page %s changed", book.ID, k)
+			return false
+		}
+	}
+
+	for _, e := range entry.Outputs {
+		if _, err := os.Stat(e.Path); err != nil {
+			log.Printf("book %s output %s missing", book.ID, e.Path)
+			return false
+		}
+	}
+
+	c.manifest.Add(entry.Outputs...)
+	return true
+}
+
+// Generated marks a book as having been generated during this run, so it is recorded once generation has completed.
+func (c *Cache) Generated(book *hugo.Book) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.generated[book] = true
+}
+
+// save records each book generated during this run
+func (c *Cache) save(_ context.Context) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// Nothing has been written in dry-run mode so there is nothing to record
+	if len(c.generated) == 0 || c.dryRun.Enabled() || c.binaryKey() == "" {
+		return nil
+	}
+
+	for book := range c.generated {
+		// Never record a book whose pages could not all be read
+		pages, err := book.Pages()
+		if err != nil {
+			return err
+		}
+
+		entry := cacheEntry{
+			Key:      c.binaryKey(),
+			Generate: book.Generate,
+			Build:    c.hugo.BuildOptions(),
+			Until:    c.index.NextChange(book),
+			Pages:    pages,
+			Outputs:  c.manifest.Book(book.ID),
+		}
+
+		buf, err := json.MarshalIndent(&entry, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		if err = os.MkdirAll(CacheDir, 0755); err != nil {
+			return err
+		}
+
+		if err = os.WriteFile(cacheFileName(book), buf, 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
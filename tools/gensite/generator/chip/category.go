@@ -3,26 +3,33 @@ package chip
 import (
 	"fmt"
 	"github.com/peter-mount/go-kernel/v2/util/strings"
+	"sync"
 )
 
-type Category map[string]map[string]*Definition
+// Category holds chip Definition's by category then name. It is safe for concurrent use.
+type Category struct {
+	mutex sync.Mutex
+	m     map[string]map[string]*Definition
+}
 
 func NewCategory() *Category {
-	m := make(Category)
-	return &m
+	return &Category{m: make(map[string]map[string]*Definition)}
 }
 
 // Put adds a Definition to the Category.
 // It returns true if the definition was added, false if an entry already exists.
 func (c *Category) Put(d *Definition) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
 	if d.Category == "" {
 		d.Category = "Miscellaneous"
 	}
 
-	m, exists := (*c)[d.Category]
+	m, exists := c.m[d.Category]
 	if !exists {
 		m = make(map[string]*Definition)
-		(*c)[d.Category] = m
+		c.m[d.Category] = m
 	}
 
 	if _, exists = m[d.Name]; exists {
@@ -33,8 +40,28 @@ func (c *Category) Put(d *Definition) bool {
 	return true
 }
 
+// RemoveBook removes all Definition's defined by a book
+func (c *Category) RemoveBook(id string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for cat, m := range c.m {
+		for name, d := range m {
+			if d.bookID == id {
+				delete(m, name)
+			}
+		}
+		if len(m) == 0 {
+			delete(c.m, cat)
+		}
+	}
+}
+
 func (c *Category) Get(cat, name string) *Definition {
-	if m, exists := (*c)[cat]; exists {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if m, exists := c.m[cat]; exists {
 		return m[name]
 	}
 	return nil
@@ -42,9 +69,12 @@ func (c *Category) Get(cat, name string) *Definition {
 
 // Categories returns a sorted slice of category names
 func (c *Category) Categories() strings.StringSlice {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
 	var a strings.StringSlice
 
-	for k, _ := range *c {
+	for k, _ := range c.m {
 		a = append(a, k)
 	}
 
@@ -52,9 +82,12 @@ func (c *Category) Categories() strings.StringSlice {
 }
 
 func (c *Category) DefinitionNames(cat string) strings.StringSlice {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
 	var a strings.StringSlice
 
-	if m, exists := (*c)[cat]; exists {
+	if m, exists := c.m[cat]; exists {
 		for k, _ := range m {
 			a = append(a, k)
 		}
@@ -68,13 +101,15 @@ func (c *Category) ForEachCategory(f func(string) error) error {
 }
 
 func (c *Category) ForEach(cat string, f func(string, *Definition) error) error {
-	m, exists := (*c)[cat]
+	c.mutex.Lock()
+	_, exists := c.m[cat]
+	c.mutex.Unlock()
 	if !exists {
 		return fmt.Errorf("category %q unknown", cat)
 	}
 
 	return c.DefinitionNames(cat).
 		ForEach(func(k string) error {
-			return f(k, m[k])
+			return f(k, c.Get(cat, k))
 		})
 }
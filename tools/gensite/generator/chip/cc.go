@@ -1,31 +1,32 @@
 package chip
 
 import (
+	"context"
 	"github.com/peter-mount/documentation/tools/gensite/util/html"
 	"strings"
 )
 
-func lccc(d *Definition) error {
+func lccc(ctx context.Context, d *Definition) error {
 	// For now lccc is just an alias for cc
-	return cc(d)
+	return cc(ctx, d)
 }
 
-func lqfp(d *Definition) error {
+func lqfp(ctx context.Context, d *Definition) error {
 	// For now lccc is just an alias for cc
-	return cc(d)
+	return cc(ctx, d)
 }
 
-func plcc(d *Definition) error {
+func plcc(ctx context.Context, d *Definition) error {
 	// For now plcc is just an alias for cc
-	return cc(d)
+	return cc(ctx, d)
 }
 
-func qfp(d *Definition) error {
+func qfp(ctx context.Context, d *Definition) error {
 	// For now qfp is just an alias for cc
-	return cc(d)
+	return cc(ctx, d)
 }
 
-func cc(d *Definition) error {
+func cc(ctx context.Context, d *Definition) error {
 	b := html.Builder()
 
 	pinCount4 := d.PinCount / 4
@@ -124,5 +125,5 @@ func cc(d *Definition) error {
 		End(). // Svg
 		FileBuilder().
 		FileHandler().
-		Write(d.Path("static/static/chipref")+".svg", d.FileInfo.ModTime())
+		Write(ctx, d.Path("static/static/chipref")+".svg", d.FileInfo.ModTime())
 }
@@ -6,25 +6,31 @@ import (
 	"github.com/peter-mount/documentation/tools/gensite"
 	"github.com/peter-mount/documentation/tools/gensite/generator"
 	"github.com/peter-mount/documentation/tools/gensite/hugo"
+	util2 "github.com/peter-mount/documentation/tools/gensite/util"
+	"github.com/peter-mount/documentation/tools/gensite/util/schema"
 	"github.com/peter-mount/go-kernel/v2/log"
 	"github.com/peter-mount/go-kernel/v2/util"
 	"github.com/peter-mount/go-kernel/v2/util/task"
-	"github.com/peter-mount/go-kernel/v2/util/walk"
 	"os"
 	"path"
 )
 
+const (
+	DefinitionsStage = "chip.definitions" // Chip definitions have been extracted from a book
+)
+
 // Chip handles the generation of CHIP pin layout images
 type Chip struct {
 	generator *generator.Generator `kernel:"inject"` // Generator
-	worker    task.Queue           `kernel:"worker"` // Worker queue
 	excel     *generator.Excel     `kernel:"inject"` // Excel
+	stats     *generator.Stats     `kernel:"inject"` // Stats
+	index     *hugo.ContentIndex   `kernel:"inject"` // ContentIndex
 	chips     *Category            // Map of named chip definitions
 	extracted util.Set[string]     // Set of book ID's so that we run once per book
 }
 
 // DefinitionHandler is called when generating the shortcode for this Definition
-type DefinitionHandler func(*Definition) error
+type DefinitionHandler func(context.Context, *Definition) error
 
 // Definition holds the details for this chip
 type Definition struct {
@@ -41,12 +47,13 @@ type Definition struct {
 	Weight      int               `yaml:"weight"`      // Weight of chip, 0=natural
 	FileInfo    os.FileInfo       `yaml:"-"`           // FileInfo of containing file
 	handler     DefinitionHandler // Handler for this chip type
+	bookID      string            // ID of the book defining this chip
 }
 
 // Generate implements the GeneratorTask interface to generate the shortcode for this chip Definition.
-func (d *Definition) Generate(_ context.Context) error {
+func (d *Definition) Generate(ctx context.Context) error {
 	if d.handler != nil {
-		return d.handler(d)
+		return d.handler(ctx, d)
 	}
 
 	return fmt.Errorf("%s has no generatator handler", d.Name)
@@ -68,18 +75,45 @@ func (d *Definition) Path(dir string) string {
 
 func (c *Chip) Start() error {
 	c.chips = NewCategory()
-	c.extracted = util.NewHashSet[string]()
+	c.extracted = util.NewSyncSet[string]()
 
 	c.generator.
 		Register("chipDefinitions",
-			task.Of().
-				Then(c.extract))
+			task.Of(c.extract),
+			generator.Provides(DefinitionsStage),
+			generator.Validates("chip", Schema())).
+		Register("chipReferenceTables",
+			task.Of(c.chipReferenceTables),
+			generator.Needs(DefinitionsStage)).
+		OnReset(c.reset)
+
+	return nil
+}
 
-	c.generator.Register("chipReferenceTables",
-		task.Of().
-			Then(c.extract).
-			Then(c.chipReferenceTables))
+// Schema returns the schema of the chip front matter, including the fields only the theme's templates use
+func Schema() *schema.Schema {
+	return schema.List(schema.Map().
+		Field("name", schema.String().Unique("chip")).
+		Field("category", schema.String()).
+		Field("subCategory", schema.String()).
+		Field("title", schema.String()).
+		Field("type", schema.String()).
+		Field("label", schema.String()).
+		Field("subLabel", schema.String()).
+		Field("pinCount", schema.Int()).
+		Field("pinOffset", schema.Int()).
+		Field("pins", schema.MapOf(schema.String())).
+		Field("weight", schema.Int()).
+		Field("alias", schema.List(schema.String())).
+		Field("note", schema.OneOf(schema.String(), schema.List(schema.String()))).
+		Field("notes", schema.List(schema.String())).
+		Field("source", schema.String()))
+}
 
+// reset discards the definitions extracted from a book so that it can be regenerated
+func (c *Chip) reset(book *hugo.Book) error {
+	c.extracted.Remove(book.ID)
+	c.chips.RemoveBook(book.ID)
 	return nil
 }
 
@@ -89,34 +123,18 @@ func (c *Chip) extract(ctx context.Context) error {
 	book := generator.GetBook(ctx)
 
 	// Only run once per Book ID
-	if c.extracted.Contains(book.ID) {
+	if !c.extracted.Add(book.ID) {
 		return nil
 	}
 
-	// Prevent us running again for this Book
-	c.extracted.Add(book.ID)
-
 	log.Printf("Scanning %s for chip designs", book.ID)
 
-	return walk.NewPathWalker().
-		IsFile().
-		PathNotContain("/reference/").
-		PathHasSuffix(".html").
-		Then(hugo.FrontMatterActionOf().
-			OtherExists("chip", c.extractChipDefinitions).
-			Walk(ctx)).
-		Walk(book.ContentPath())
+	return c.index.Walk(ctx, book, hugo.FrontMatterActionOf().
+		OtherExists("chip", c.extractChipDefinitions))
 }
 
 // extractChipDefinitions extracts all definitions from a specific hugo page
 func (c *Chip) extractChipDefinitions(ctx context.Context, _ *hugo.FrontMatter) error {
-	c.worker.AddPriorityTask(tools.PriorityChip,
-		task.Of(c.extractChipDefTask).
-			WithContext(ctx, "other", "fileInfo"))
-	return nil
-}
-
-func (c *Chip) extractChipDefTask(ctx context.Context) error {
 	return util.ForEachInterface(ctx.Value("other"), func(e interface{}) error {
 		return util.IfMap(e, func(m map[interface{}]interface{}) error {
 			pinCount, ok := util.DecodeInt(m["pinCount"], 0)
@@ -141,6 +159,7 @@ func (c *Chip) extractChipDefTask(ctx context.Context) error {
 				Pins:        make(map[int]string),
 				Weight:      weight,
 				FileInfo:    ctx.Value("fileInfo").(os.FileInfo),
+				bookID:      generator.GetBook(ctx).ID,
 			}
 
 			if v.Type == "pga" {
@@ -223,8 +242,13 @@ func (c *Chip) extractChipDefTask(ctx context.Context) error {
 				return fmt.Errorf("%s already defined", v.Name)
 			}
 
+			// The chip is generated from just this page
+			producer := util2.NewProducer("chipDefinitions", v.bookID)
+			producer.AddSource(hugo.FilePath(ctx))
+
 			task.GetQueue(ctx).
-				AddPriorityTask(tools.PriorityChip, v.Generate)
+				AddPriorityTask(tools.PriorityChip, c.stats.Timed(producer, generator.PhaseWrite, task.Of(v.Generate).
+					WithValue(util2.ProducerKey, producer)))
 			return nil
 		})
 	})
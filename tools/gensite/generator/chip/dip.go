@@ -1,6 +1,7 @@
 package chip
 
 import (
+	"context"
 	"github.com/peter-mount/documentation/tools/gensite/util/html"
 )
 
@@ -19,7 +20,7 @@ const (
 )
 
 // dip Dual Inline Pin chip layout
-func dip(d *Definition) error {
+func dip(ctx context.Context, d *Definition) error {
 	b := html.Builder()
 
 	pinCount2 := d.PinCount / 2
@@ -78,5 +79,5 @@ func dip(d *Definition) error {
 	return b.
 		FileBuilder().
 		FileHandler().
-		Write(d.Path("static/static/chipref")+".svg", d.FileInfo.ModTime())
+		Write(ctx, d.Path("static/static/chipref")+".svg", d.FileInfo.ModTime())
 }
@@ -1,6 +1,7 @@
 package chip
 
 import (
+	"context"
 	"fmt"
 	"github.com/peter-mount/documentation/tools/gensite/util/html"
 	"github.com/peter-mount/go-kernel/v2/util"
@@ -17,7 +18,7 @@ const (
 //
 // pinCount is the number of pins on both axes. Not all pins need to be defined.
 // Pins are labeled A1, A2, K10 etc where A..Z is Y-axis whilst 1..n X-axis
-func pga(d *Definition) error {
+func pga(ctx context.Context, d *Definition) error {
 	b := html.Builder()
 
 	spacing := dipPinSpacingV
@@ -132,7 +133,7 @@ func pga(d *Definition) error {
 		End(). // Svg
 		FileBuilder().
 		FileHandler().
-		Write(d.Path("static/static/chipref")+".svg", d.FileInfo.ModTime())
+		Write(ctx, d.Path("static/static/chipref")+".svg", d.FileInfo.ModTime())
 }
 
 // pgaDecodePins decodes Pins map in yaml to the matrix for display
@@ -3,11 +3,9 @@ package chip
 import (
 	"context"
 	"fmt"
-	"github.com/peter-mount/documentation/tools/gensite"
 	"github.com/peter-mount/documentation/tools/gensite/generator"
 	"github.com/peter-mount/documentation/tools/gensite/hugo"
 	"github.com/peter-mount/documentation/tools/gensite/util"
-	"github.com/peter-mount/go-kernel/v2/util/task"
 )
 
 // Generates the chip reference tables.
@@ -15,21 +13,10 @@ import (
 func (c *Chip) chipReferenceTables(ctx context.Context) error {
 	book := generator.GetBook(ctx)
 
-	// Requeue so it runs later
-	task.GetQueue(ctx).
-		AddPriorityTask(tools.PriorityChip, task.Of(c.chipReferenceTablesTask).
-			WithValue(generator.BookKey, book))
-
-	return nil
-}
-
-func (c *Chip) chipReferenceTablesTask(ctx context.Context) error {
-	book := generator.GetBook(ctx)
-
 	return c.chips.ForEachCategory(func(cat string) error {
 		return util.WithTable().
-			AsCSV(book.StaticPath(cat+".csv"), book.Modified()).
-			AsExcel(c.excel.Get(book.ID, book.Modified())).
+			AsCSV(ctx, book.StaticPath(cat+".csv"), book.Modified()).
+			AsExcel(c.excel.Get(ctx, book.ID, book.Modified())).
 			Do(c.chipReferenceTable(book, cat))
 	})
 }
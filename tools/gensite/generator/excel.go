@@ -3,58 +3,118 @@ package generator
 import (
 	"context"
 	"github.com/peter-mount/documentation/tools/gensite"
+	"github.com/peter-mount/documentation/tools/gensite/hugo"
 	"github.com/peter-mount/documentation/tools/gensite/util"
 	"github.com/peter-mount/go-kernel/v2/util/task"
 	"path"
+	"strings"
+	"sync"
 	"time"
 )
 
 // Excel service that manages multiple Workbooks by ID and ensures they are written
 type Excel struct {
-	worker   task.Queue `kernel:"worker"` // Worker queue
-	builders map[string]*provider
+	generator *Generator `kernel:"inject"` // Generator
+	manifest  *Manifest  `kernel:"inject"` // Manifest
+	stats     *Stats     `kernel:"inject"` // Stats
+	mutex     sync.Mutex
+	builders  map[string]*provider
 }
 
 // provider implements the ExcelProvider interface
 type provider struct {
-	name     string
-	modified time.Time
-	builder  util.ExcelBuilder
+	mutex     sync.Mutex
+	name      string
+	modified  time.Time
+	builder   util.ExcelBuilder
+	producers []*util.Producer // Producers of the sheets in the workbook
 }
 
 func (p *provider) BuildExcel(f func(builder util.ExcelBuilder) util.ExcelBuilder) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
 	p.builder = f(p.builder)
 	return nil
 }
 
 func (e *Excel) Start() error {
 	e.builders = make(map[string]*provider)
+
+	// Workbooks are named after the book so discard it when the book is regenerated
+	e.generator.OnReset(func(book *hugo.Book) error {
+		e.mutex.Lock()
+		defer e.mutex.Unlock()
+		delete(e.builders, book.ID)
+		return nil
+	})
+
 	return nil
 }
 
-// Get returns a named ExcelProvider and ensures it is written to disk.
-func (e *Excel) Get(name string, modified time.Time) util.ExcelProvider {
-	if provider, exists := e.builders[name]; exists {
-		return provider
+// Get returns a named ExcelProvider and ensures it is written to disk by the queue in the supplied context.
+func (e *Excel) Get(ctx context.Context, name string, modified time.Time) util.ExcelProvider {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	p, exists := e.builders[name]
+	if !exists {
+		p = &provider{name: name, modified: modified}
+		e.builders[name] = p
+
+		// Add task that will actually write the Excel file
+		task.GetQueue(ctx).AddPriorityTask(tools.PriorityExcel, e.stats.Timed(util.GetProducer(ctx), PhaseWrite, p.task))
+		e.manifest.Claim(ctx, p.fileName())
 	}
 
-	provider := &provider{name: name, modified: modified}
-	e.builders[name] = provider
+	p.addProducer(util.GetProducer(ctx))
+	return p
+}
 
-	// Add task that will actually write the Excel file
-	e.worker.AddPriorityTask(tools.PriorityExcel, provider.task)
+func (p *provider) addProducer(producer *util.Producer) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
 
-	return provider
+	if producer == nil {
+		return
+	}
+	for _, e := range p.producers {
+		if e == producer {
+			return
+		}
+	}
+	p.producers = append(p.producers, producer)
 }
 
-func (p *provider) task(_ context.Context) error {
+// producer returns a Producer for the workbook made up of the producers of each sheet.
+// The caller must hold the lock.
+func (p *provider) producer() *util.Producer {
+	var generators []string
+	book := ""
+	for _, e := range p.producers {
+		generators = append(generators, e.Generator)
+		if book == "" {
+			book = e.Book
+		}
+	}
+	return util.NewProducer(strings.Join(generators, ","), book, p.producers...)
+}
+
+// fileName returns the path of the workbook
+func (p *provider) fileName() string {
+	return path.Join("static/static/book/", p.name+".xlsx")
+}
+
+func (p *provider) task(ctx context.Context) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
 	if p.builder != nil {
 		// NOTE: Use WriteAlways with Excel as we cannot compare an existing version due to xlsx files being zip files
 		// so the timestamps inside the zip file are always different causing the generated file to differ
 		// even if the actual content is identical.
 		return p.builder.
 			FileHandler().
-			WriteAlways(path.Join("static/static/book/", p.name+".xlsx"), p.modified)
+			WriteAlways(context.WithValue(ctx, util.ProducerKey, p.producer()), p.fileName(), p.modified)
 	}
 	return nil
 }
@@ -5,41 +5,155 @@ import (
 	"fmt"
 	"github.com/peter-mount/documentation/tools/gensite"
 	"github.com/peter-mount/documentation/tools/gensite/hugo"
+	"github.com/peter-mount/documentation/tools/gensite/util"
 	"github.com/peter-mount/go-kernel/v2/log"
 	"github.com/peter-mount/go-kernel/v2/util/task"
+	"runtime"
+	"sync"
 )
 
 // Generator is a kernel Service which handles the generation of content based on page metadata.
 type Generator struct {
-	bookShelf  *hugo.BookShelf      `kernel:"inject"`
-	worker     task.Queue           `kernel:"worker"` // Worker queue
-	generators map[string]task.Task // Map of available generators
+	bookShelf  *hugo.BookShelf          `kernel:"inject"`
+	cache      *Cache                   `kernel:"inject"`
+	index      *hugo.ContentIndex       `kernel:"inject"`
+	manifest   *Manifest                `kernel:"inject"`
+	prune      *Prune                   `kernel:"inject"`
+	stats      *Stats                   `kernel:"inject"`
+	validate   *util.Validate           `kernel:"inject"`
+	worker     task.Queue               `kernel:"worker"`                                                                // Worker queue
+	jobs       *int                     `kernel:"flag,j,Number of books to generate concurrently (0 for one per cpu),1"` // Number of concurrent books
+	watch      *bool                    `kernel:"flag,watch,Regenerate books when their content changes"`                // Watch for changes
+	generators *graph                   // Dependency graph of available generators
+	resets     []hugo.BookHandler       // Handlers to discard book state before regenerating
+	changes    map[string]ChangeHandler // Handlers for changes outside of books by directory
 }
 
 func (g *Generator) Start() error {
-	g.generators = make(map[string]task.Task)
+	g.generators = newGraph()
+	g.changes = make(map[string]ChangeHandler)
 
 	g.worker.AddPriorityTask(tools.PriorityImmediate, g.generate)
 	return nil
 }
 
-// Register a named Handler
-func (g *Generator) Register(n string, h task.Task) *Generator {
-	if _, exists := g.generators[n]; exists {
+// Register a named Handler.
+//
+// The optional Dependency's declare which stages this generator Needs & Provides.
+// When a book is generated, the generators it lists are run in dependency order, with any generator providing a
+// needed stage being run first, even if the book does not list it.
+func (g *Generator) Register(n string, h task.Task, deps ...Dependency) *Generator {
+	if g.Registered(n) {
 		panic(fmt.Errorf("GeneratorHandler %s already registered", n))
 	}
 
-	g.generators[n] = h
+	nd := &node{name: n, task: h}
+	for _, d := range deps {
+		d(nd)
+	}
+
+	g.generators.add(nd)
+	return g
+}
+
+// Registered returns true if a generator has been registered with this name
+func (g *Generator) Registered(n string) bool {
+	_, exists := g.generators.nodes[n]
+	return exists
+}
+
+// OnReset registers a handler which is called before a book is regenerated in watch mode.
+// Generators holding state for a book, e.g. to only extract it once, must use this to discard that state otherwise
+// the book will not be regenerated.
+func (g *Generator) OnReset(h hugo.BookHandler) *Generator {
+	g.resets = append(g.resets, h)
+	return g
+}
+
+// ChangeHandler is called in watch mode when a file outside any book has changed
+type ChangeHandler func(ctx context.Context, fileName string) error
+
+// OnChange registers a ChangeHandler for files within a directory, e.g. "config".
+// In watch mode that directory is also watched for changes.
+func (g *Generator) OnChange(dir string, h ChangeHandler) *Generator {
+	if _, exists := g.changes[dir]; exists {
+		panic(fmt.Errorf("ChangeHandler for %s already registered", dir))
+	}
+	g.changes[dir] = h
 	return g
 }
 
-func (g *Generator) generate(_ context.Context) error {
-	if err := g.bookShelf.Books().ForEach(g.invokeBook); err != nil {
+func (g *Generator) generate(ctx context.Context) error {
+	// All generators have registered by now so check the graph is valid before doing any work
+	if err := g.generators.validate(); err != nil {
 		return err
 	}
+
+	// Check the front matter of each book rather than generating them
+	if g.validate.Enabled() {
+		task.GetQueue(ctx).AddTask(g.validateBooks)
+		return nil
+	}
+
+	// Queue the books rather than run them now so that any other immediate tasks,
+	// e.g. cleaning up old content, have run first.
+	task.GetQueue(ctx).AddTask(func(ctx context.Context) error {
+		jobs := *g.jobs
+		if jobs < 1 {
+			jobs = runtime.NumCPU()
+		}
+
+		return g.invokeBooks(ctx, g.bookShelf.Books(), jobs)
+	})
+
+	if *g.watch {
+		g.worker.AddPriorityTask(tools.PriorityWatch, g.startWatcher)
+	}
+
 	return nil
 }
 
+// invokeBooks runs the generators for each book, processing up to jobs books concurrently.
+// The generators for an individual book always run in sequence.
+// If any book fails then the first error is returned once all running books have completed.
+func (g *Generator) invokeBooks(ctx context.Context, books hugo.Books, jobs int) error {
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	var result error
+
+	sem := make(chan struct{}, jobs)
+	for _, book := range books {
+		sem <- struct{}{}
+
+		mutex.Lock()
+		failed := result != nil
+		mutex.Unlock()
+		if failed {
+			<-sem
+			break
+		}
+
+		wg.Add(1)
+		go func(book *hugo.Book) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			if err := g.invokeBook(ctx, book); err != nil {
+				mutex.Lock()
+				if result == nil {
+					result = fmt.Errorf("book %s: %w", book.ID, err)
+				}
+				mutex.Unlock()
+			}
+		}(book)
+	}
+
+	wg.Wait()
+	return result
+}
+
 const (
 	BookKey = "hugo.Book"
 )
@@ -51,17 +165,47 @@ func GetBook(ctx context.Context) *hugo.Book {
 	return nil
 }
 
-func (g *Generator) invokeBook(book *hugo.Book) error {
-	return book.Generate.ForEach(func(n string) error {
-		h, exists := g.generators[n]
-		if exists {
-			g.worker.AddTask(h.WithValue(BookKey, book))
-		} else {
-			// Log a warning but ignore - could be an invalid config or the generator is not deployed.
-			// Originally this was a fatal error, but now we just ignore to allow custom tools to be run
-			log.Printf("book %s GeneratorHandler %s is not registered", book.ID, n)
+func (g *Generator) invokeBook(ctx context.Context, book *hugo.Book) error {
+	if g.cache.Unchanged(book) {
+		log.Printf("book %s unchanged, skipping", book.ID)
+		return nil
+	}
+
+	// The pages read by the generators are only needed whilst this book is being generated
+	defer g.index.Release(book)
+
+	nodes, unknown := g.generators.scheduleBook(book)
+
+	for _, n := range unknown {
+		// Log a warning but ignore - could be an invalid config or the generator is not deployed.
+		// Originally this was a fatal error, but now we just ignore to allow custom tools to be run
+		log.Printf("book %s GeneratorHandler %s is not registered", book.ID, n)
+	}
+
+	// Run in dependency order. Any tasks these queue will run on the worker once all books have been processed.
+	// Each generator has a Producer so the files it writes can be traced back to it & the pages used.
+	producers := make(map[*node]*util.Producer)
+	for _, n := range nodes {
+		var depends []*util.Producer
+		for _, d := range g.generators.dependencies(n) {
+			if p, exists := producers[d]; exists {
+				depends = append(depends, p)
+			}
 		}
+		p := util.NewProducer(n.name, book.ID, depends...)
+		producers[n] = p
 
-		return nil
-	})
+		phase := PhaseWrite
+		if len(n.provides) > 0 {
+			phase = PhaseExtract
+		}
+
+		t := n.task.WithValue(BookKey, book).WithValue(util.ProducerKey, p)
+		if err := g.stats.Timed(p, phase, t).Do(ctx); err != nil {
+			return err
+		}
+	}
+
+	g.cache.Generated(book)
+	return nil
 }
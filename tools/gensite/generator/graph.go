@@ -0,0 +1,230 @@
+package generator
+
+import (
+	"fmt"
+	"github.com/peter-mount/documentation/tools/gensite/hugo"
+	"github.com/peter-mount/documentation/tools/gensite/util/schema"
+	"github.com/peter-mount/go-kernel/v2/util/task"
+	"sort"
+	"strings"
+)
+
+// Dependency declares the inputs & outputs of a registered generator.
+// Inputs & outputs are named stages, e.g. "6502.opcodes" being the extracted opcodes for a book.
+type Dependency func(*node)
+
+// Needs declares the stages a generator requires to have been run before it for the same book.
+func Needs(stages ...string) Dependency {
+	return func(n *node) {
+		n.needs = append(n.needs, stages...)
+	}
+}
+
+// Provides declares the stages a generator produces for a book.
+func Provides(stages ...string) Dependency {
+	return func(n *node) {
+		n.provides = append(n.provides, stages...)
+	}
+}
+
+// Validates declares the Schema of a front matter key a generator reads from a book's pages, used by -validate.
+func Validates(key string, s *schema.Schema) Dependency {
+	return func(n *node) {
+		if n.schemas == nil {
+			n.schemas = make(map[string]*schema.Schema)
+		}
+		n.schemas[key] = s
+	}
+}
+
+// Translated declares a generator is also run for the translations of a book, see hugo.BookShelf.
+// It must write any pages using hugo.Book PageName & I18n so that they are in the book's language.
+// Generators writing language neutral files, e.g. source code, are only run for the original book.
+func Translated() Dependency {
+	return func(n *node) {
+		n.translated = true
+	}
+}
+
+// node is a registered generator within the dependency graph
+type node struct {
+	name       string                    // Name of the generator
+	order      int                       // Registration order, used to keep scheduling stable
+	task       task.Task                 // Task to run
+	needs      []string                  // Stages required before this node
+	provides   []string                  // Stages this node produces
+	schemas    map[string]*schema.Schema // Schema of the front matter keys this node reads
+	translated bool                      // true if run for translations
+}
+
+// graph of registered generators
+type graph struct {
+	nodes    map[string]*node // Generators by name
+	provider map[string]*node // Generator by the stage it provides
+}
+
+func newGraph() *graph {
+	return &graph{
+		nodes:    make(map[string]*node),
+		provider: make(map[string]*node),
+	}
+}
+
+func (g *graph) add(n *node) {
+	n.order = len(g.nodes)
+	g.nodes[n.name] = n
+}
+
+// names returns the generator names in registration order
+func (g *graph) names() []string {
+	var a []string
+	for k := range g.nodes {
+		a = append(a, k)
+	}
+	sort.SliceStable(a, func(i, j int) bool {
+		return g.nodes[a[i]].order < g.nodes[a[j]].order
+	})
+	return a
+}
+
+// dependencies returns the nodes which provide the stages n needs
+func (g *graph) dependencies(n *node) []*node {
+	var a []*node
+	for _, s := range n.needs {
+		if p, exists := g.provider[s]; exists && p != n {
+			a = append(a, p)
+		}
+	}
+	return a
+}
+
+// validate resolves the providers of each stage, then checks every stage needed has a provider and that the graph
+// contains no cycles. All problems found are reported in a single error.
+func (g *graph) validate() error {
+	var errs []string
+
+	g.provider = make(map[string]*node)
+	for _, name := range g.names() {
+		n := g.nodes[name]
+		for _, s := range n.provides {
+			if p, exists := g.provider[s]; exists {
+				errs = append(errs, fmt.Sprintf("stage %q provided by both %s and %s", s, p.name, n.name))
+			} else {
+				g.provider[s] = n
+			}
+		}
+	}
+
+	for _, name := range g.names() {
+		n := g.nodes[name]
+		for _, s := range n.needs {
+			if p, exists := g.provider[s]; !exists {
+				errs = append(errs, fmt.Sprintf("%s needs stage %q which no generator provides", n.name, s))
+			} else if n.translated && !p.translated {
+				errs = append(errs, fmt.Sprintf("%s is translated but needs stage %q from %s which is not", n.name, s, p.name))
+			}
+		}
+	}
+
+	errs = append(errs, g.cycles()...)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid generator graph:\n\t%s", strings.Join(errs, "\n\t"))
+	}
+	return nil
+}
+
+const (
+	unvisited = iota
+	visiting
+	visited
+)
+
+// cycles returns a description of each cycle found in the graph
+func (g *graph) cycles() []string {
+	var errs []string
+	state := make(map[*node]int)
+	var stack []*node
+
+	var visit func(n *node)
+	visit = func(n *node) {
+		state[n] = visiting
+		stack = append(stack, n)
+
+		for _, d := range g.dependencies(n) {
+			switch state[d] {
+			case unvisited:
+				visit(d)
+			case visiting:
+				// Found a cycle, report it from where d appears on the stack
+				var path []string
+				for i := len(stack) - 1; i >= 0; i-- {
+					path = append(path, stack[i].name)
+					if stack[i] == d {
+						break
+					}
+				}
+				path = append(path, n.name)
+				errs = append(errs, "cycle "+strings.Join(path, " -> "))
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[n] = visited
+	}
+
+	for _, name := range g.names() {
+		if n := g.nodes[name]; state[n] == unvisited {
+			visit(n)
+		}
+	}
+
+	return errs
+}
+
+// scheduleBook returns the nodes to run, in order, for a book. A translation only runs Translated nodes.
+func (g *graph) scheduleBook(book *hugo.Book) ([]*node, []string) {
+	nodes, unknown := g.schedule(book.Generate)
+	if !book.Translation() {
+		return nodes, unknown
+	}
+
+	var result []*node
+	for _, n := range nodes {
+		if n.translated {
+			result = append(result, n)
+		}
+	}
+	return result, unknown
+}
+
+// schedule returns the nodes to run, in order, for the requested generator names.
+// Any generator providing a stage needed by a requested generator is included even if not requested.
+// Names which are not registered are returned separately.
+func (g *graph) schedule(requested []string) ([]*node, []string) {
+	var unknown []string
+	var result []*node
+	done := make(map[*node]bool)
+
+	var visit func(n *node)
+	visit = func(n *node) {
+		if done[n] {
+			return
+		}
+		done[n] = true
+		for _, d := range g.dependencies(n) {
+			visit(d)
+		}
+		result = append(result, n)
+	}
+
+	for _, name := range requested {
+		if n, exists := g.nodes[name]; exists {
+			visit(n)
+		} else {
+			unknown = append(unknown, name)
+		}
+	}
+
+	return result, unknown
+}
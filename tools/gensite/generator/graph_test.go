@@ -0,0 +1,100 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+)
+
+func testGraph(nodes ...*node) *graph {
+	g := newGraph()
+	for _, n := range nodes {
+		g.add(n)
+	}
+	return g
+}
+
+func testNode(name string, deps ...Dependency) *node {
+	n := &node{name: name}
+	for _, d := range deps {
+		d(n)
+	}
+	return n
+}
+
+func TestGraph_schedule(t *testing.T) {
+	g := testGraph(
+		testNode("index", Needs("opcodes", "api")),
+		testNode("opcodes", Provides("opcodes")),
+		testNode("api", Provides("api")),
+		testNode("grid", Needs("opcodes")),
+	)
+
+	if err := g.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	nodes, unknown := g.schedule([]string{"grid", "index", "custom"})
+
+	var names []string
+	for _, n := range nodes {
+		names = append(names, n.name)
+	}
+
+	if got, want := strings.Join(names, ","), "opcodes,grid,api,index"; got != want {
+		t.Errorf("schedule got %q want %q", got, want)
+	}
+
+	if len(unknown) != 1 || unknown[0] != "custom" {
+		t.Errorf("unknown got %v", unknown)
+	}
+}
+
+func TestGraph_cycle(t *testing.T) {
+	g := testGraph(
+		testNode("a", Needs("b"), Provides("a")),
+		testNode("b", Needs("a"), Provides("b")),
+	)
+
+	err := g.validate()
+	if err == nil {
+		t.Fatal("expected cycle to be detected")
+	}
+	if !strings.Contains(err.Error(), "cycle b -> a -> b") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestGraph_missingProvider(t *testing.T) {
+	g := testGraph(
+		testNode("a", Needs("b")),
+		testNode("c", Provides("d")),
+		testNode("e", Provides("d")),
+	)
+
+	err := g.validate()
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	for _, s := range []string{`a needs stage "b"`, `stage "d" provided by both c and e`} {
+		if !strings.Contains(err.Error(), s) {
+			t.Errorf("error %v does not contain %q", err, s)
+		}
+	}
+}
+
+func TestGraph_translated(t *testing.T) {
+	g := testGraph(
+		testNode("index", Needs("opcodes"), Translated()),
+		testNode("opcodes", Provides("opcodes")),
+	)
+
+	err := g.validate()
+	if err == nil || !strings.Contains(err.Error(), `index is translated but needs stage "opcodes" from opcodes`) {
+		t.Fatalf("got %v", err)
+	}
+
+	Translated()(g.nodes["opcodes"])
+	if err := g.validate(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,287 @@
+package m6502
+
+import (
+	"context"
+	"fmt"
+	"github.com/peter-mount/documentation/tools/gensite/generator"
+	"github.com/peter-mount/documentation/tools/gensite/generator/assembly"
+	"github.com/peter-mount/documentation/tools/gensite/hugo"
+	"github.com/peter-mount/documentation/tools/gensite/util"
+	strings2 "github.com/peter-mount/go-kernel/v2/util/strings"
+	"html"
+	"sort"
+	"strings"
+)
+
+const (
+	Added   = "Added"   // Opcode is only available on the later variant
+	Removed = "Removed" // Opcode is only available on the earlier variant
+	Changed = "Changed" // Opcode has bytes or cycles which differ between the variants
+)
+
+// Change is a difference in an opcode between two variants of the processor, e.g. 6502 & 65c02
+type Change struct {
+	From   string           // Earlier variant
+	To     string           // Later variant
+	Change string           // Added, Removed or Changed
+	Opcode *assembly.Opcode // The opcode
+	Notes  []string         // Notes about the bytes or cycles which apply to only one of the variants
+}
+
+// Variants returns the variants in the compatibility of some opcodes, ordered by the number of opcodes each one
+// supports so the original processor is first, e.g. 6502, 65c02 & 65816
+func Variants(ops []*assembly.Opcode) []string {
+	count := map[string]int{}
+	for _, op := range ops {
+		if op.Compatibility != nil {
+			for variant, supported := range *op.Compatibility {
+				if supported {
+					count[variant]++
+				}
+			}
+		}
+	}
+
+	var variants []string
+	for variant := range count {
+		variants = append(variants, variant)
+	}
+	sort.SliceStable(variants, func(a, b int) bool {
+		if count[variants[a]] == count[variants[b]] {
+			return variants[a] < variants[b]
+		}
+		return count[variants[a]] < count[variants[b]]
+	})
+	return variants
+}
+
+// Supports returns true if an opcode is available on a variant
+func Supports(op *assembly.Opcode, variant string) bool {
+	return op.Compatibility != nil && (*op.Compatibility)[variant]
+}
+
+// VariantNotes returns the notes about the bytes & cycles of an opcode which apply to only a variant.
+// These notes start with the variant, e.g. "65816: Add 1 cycle if m=0 (16-bit memory/accumulator)"
+func VariantNotes(op *assembly.Opcode, variant string) []string {
+	var r []string
+	for _, t := range []*assembly.OpcodeType{op.Bytes, op.Cycles} {
+		if t == nil {
+			continue
+		}
+		for _, n := range t.Notes {
+			if v, _, ok := strings.Cut(n.Value, ":"); ok && strings.EqualFold(strings.TrimSpace(v), variant) {
+				r = append(r, n.Value)
+			}
+		}
+	}
+	return r
+}
+
+// Changes returns the opcodes added, removed or changed between each pair of variants, in opcode order
+func Changes(ops []*assembly.Opcode, variants []string) []*Change {
+	var r []*Change
+	for i, from := range variants {
+		for _, to := range variants[i+1:] {
+			for _, op := range ops {
+				inFrom, inTo := Supports(op, from), Supports(op, to)
+				switch {
+				case inTo && !inFrom:
+					r = append(r, &Change{From: from, To: to, Change: Added, Opcode: op})
+				case inFrom && !inTo:
+					r = append(r, &Change{From: from, To: to, Change: Removed, Opcode: op})
+				case inFrom && inTo:
+					notes := append(VariantNotes(op, from), VariantNotes(op, to)...)
+					if len(notes) > 0 {
+						r = append(r, &Change{From: from, To: to, Change: Changed, Opcode: op, Notes: notes})
+					}
+				}
+			}
+		}
+	}
+	return r
+}
+
+// opcodes returns a copy of the opcodes of a book in opcode order
+func (s *M6502) opcodes(book *hugo.Book) []*assembly.Opcode {
+	var ops []*assembly.Opcode
+	s.Instructions(book).Iterator().ForEach(func(op *assembly.Opcode) {
+		ops = append(ops, op)
+	})
+	sort.SliceStable(ops, func(a, b int) bool {
+		return assembly.DecodeOpcode(ops[a].Code) < assembly.DecodeOpcode(ops[b].Code)
+	})
+	return ops
+}
+
+// changes returns the changes between each pair of variants of the opcodes of a book
+func (s *M6502) changes(book *hugo.Book) []*Change {
+	ops := s.opcodes(book)
+	return Changes(ops, Variants(ops))
+}
+
+func (s *M6502) writeCompatibility(ctx context.Context) error {
+	book := generator.GetBook(ctx)
+	changes := s.changes(book)
+
+	// A table per pair of variants
+	gen := &assembly.IndexGenerator{
+		Name:  "compatibility",
+		Title: "Compatibility between processors",
+		Desc:  "Opcodes added, removed or changed between each processor",
+		Class: "opIndex1",
+		Paginator: func(rowCount int, entry interface{}) bool {
+			a, b := entry.(*Change), changes[rowCount-1]
+			return a.From != b.From || a.To != b.To
+		},
+		Header: func(slice strings2.StringSlice, rowCount int) strings2.StringSlice {
+			if len(changes) > 0 {
+				c := changes[rowCount]
+				slice = append(slice, fmt.Sprintf("<caption>%s to %s</caption>", html.EscapeString(c.From), html.EscapeString(c.To)))
+			}
+			return append(slice, "<thead><tr>",
+				"<th>Change</th>",
+				"<th>Instruction</th>",
+				"<th>Opcode</th>",
+				"<th>Bytes</th>",
+				"<th>Cycles</th>",
+				"<th>Notes</th>",
+				"</tr></thead>")
+		},
+		Body: func(slice strings2.StringSlice, _ int, entry interface{}) strings2.StringSlice {
+			c := entry.(*Change)
+			return append(slice, fmt.Sprintf("<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>",
+				c.Change, M6502OpcodeFormatter(c.Opcode), c.Opcode.Code, c.Opcode.Bytes.String(), c.Opcode.Cycles.String(),
+				html.EscapeString(strings.Join(c.Notes, "; "))))
+		},
+	}
+
+	return gen.Write(ctx, book, assembly.Iterator(changes))
+}
+
+// writeCompatibilityTable writes the changes between each pair of variants to the book's csv & Excel files
+func (s *M6502) writeCompatibilityTable(ctx context.Context) error {
+	book := generator.GetBook(ctx)
+	changes := s.changes(book)
+
+	return util.WithTable().
+		AsCSV(ctx, book.StaticPath("compatibility.csv"), book.Modified()).
+		AsExcel(s.excel.Get(ctx, book.ID, book.Modified())).
+		Do(&util.Table{
+			Title: "compatibility",
+			Columns: []string{
+				"From",
+				"To",
+				"Change",
+				"Opcode",
+				"Instruction",
+				"Addressing",
+				"Bytes",
+				"Cycles",
+				"Notes",
+			},
+			RowCount: len(changes),
+			GetRow: func(r int) interface{} {
+				return changes[r]
+			},
+			Transform: func(i interface{}) []interface{} {
+				c := i.(*Change)
+				return []interface{}{
+					c.From,
+					c.To,
+					c.Change,
+					c.Opcode.Code,
+					c.Opcode.Op,
+					c.Opcode.Addressing,
+					c.Opcode.Bytes.String(),
+					c.Opcode.Cycles.String(),
+					strings.Join(c.Notes, "\n"),
+				}
+			},
+		})
+}
+
+// variantOpcodes returns the opcodes of a book in mnemonic & addressing mode order, and their variants
+func (s *M6502) variantOpcodes(book *hugo.Book) ([]*assembly.Opcode, []string) {
+	ops := s.opcodes(book)
+	variants := Variants(ops)
+	sort.SliceStable(ops, func(a, b int) bool {
+		if ops[a].Op == ops[b].Op {
+			return ops[a].Addressing < ops[b].Addressing
+		}
+		return ops[a].Op < ops[b].Op
+	})
+	return ops, variants
+}
+
+// variantCodes returns the opcode on each variant, "" where it is unsupported
+func variantCodes(op *assembly.Opcode, variants []string) []string {
+	var r []string
+	for _, v := range variants {
+		if Supports(op, v) {
+			r = append(r, op.Code)
+		} else {
+			r = append(r, "")
+		}
+	}
+	return r
+}
+
+// writeVariants writes the matrix of mnemonic & addressing mode against the variants supporting them
+func (s *M6502) writeVariants(ctx context.Context) error {
+	book := generator.GetBook(ctx)
+	ops, variants := s.variantOpcodes(book)
+
+	gen := &assembly.IndexGenerator{
+		Name:  "variants",
+		Title: "Instructions by processor",
+		Desc:  "Instructions & addressing modes available on each processor",
+		Class: "opIndex1",
+		Header: func(slice strings2.StringSlice, _ int) strings2.StringSlice {
+			slice = append(slice, "<thead><tr>", "<th>Instruction</th>")
+			for _, v := range variants {
+				slice = append(slice, "<th>"+html.EscapeString(v)+"</th>")
+			}
+			return append(slice, "</tr></thead>")
+		},
+		Body: func(slice strings2.StringSlice, _ int, entry interface{}) strings2.StringSlice {
+			op := entry.(*assembly.Opcode)
+			r := "<tr><td>" + M6502OpcodeFormatter(op) + "</td>"
+			for _, code := range variantCodes(op, variants) {
+				if code == "" {
+					code = "&hyphen;"
+				}
+				r = r + "<td>" + code + "</td>"
+			}
+			return append(slice, r+"</tr>")
+		},
+	}
+
+	return gen.Write(ctx, book, assembly.Iterator(ops))
+}
+
+// writeVariantsTable writes the matrix of mnemonic & addressing mode against the variants supporting them to the
+// book's csv & Excel files
+func (s *M6502) writeVariantsTable(ctx context.Context) error {
+	book := generator.GetBook(ctx)
+	ops, variants := s.variantOpcodes(book)
+
+	return util.WithTable().
+		AsCSV(ctx, book.StaticPath("variants.csv"), book.Modified()).
+		AsExcel(s.excel.Get(ctx, book.ID, book.Modified())).
+		Do(&util.Table{
+			Title:    "variants",
+			Columns:  append([]string{"Instruction", "Addressing"}, variants...),
+			RowCount: len(ops),
+			GetRow: func(r int) interface{} {
+				return ops[r]
+			},
+			Transform: func(i interface{}) []interface{} {
+				op := i.(*assembly.Opcode)
+				r := []interface{}{op.Op, op.Addressing}
+				for _, code := range variantCodes(op, variants) {
+					r = append(r, code)
+				}
+				return r
+			},
+		})
+}
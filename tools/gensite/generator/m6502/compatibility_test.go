@@ -0,0 +1,52 @@
+package m6502
+
+import (
+	"github.com/peter-mount/documentation/tools/gensite/generator/assembly"
+	"github.com/peter-mount/documentation/tools/gensite/util"
+	util2 "github.com/peter-mount/go-kernel/v2/util"
+	"strings"
+	"testing"
+)
+
+func TestChanges(t *testing.T) {
+	op := func(code string, cycleNote string, variants ...string) *assembly.Opcode {
+		c := util2.NewSortedMap[bool]()
+		for _, v := range variants {
+			(*c)[v] = true
+		}
+		o := &assembly.Opcode{Code: code, Compatibility: c, Cycles: &assembly.OpcodeType{Value: "2"}}
+		if cycleNote != "" {
+			o.Cycles.Notes = []*util.Note{{Value: cycleNote}}
+		}
+		return o
+	}
+
+	ops := []*assembly.Opcode{
+		op("EA", "", "6502", "65c02", "65816"),
+		op("1A", "", "65c02", "65816"),
+		op("02", "", "65816"),
+		op("69", "65C02: Add 1 cycle if d=1", "6502", "65c02", "65816"),
+		op("6D", "Add 1 cycle if adding index crosses a page boundary", "6502", "65c02", "65816"),
+	}
+
+	variants := Variants(ops)
+	if got := strings.Join(variants, " "); got != "6502 65c02 65816" {
+		t.Fatalf("variants got %q", got)
+	}
+
+	var got []string
+	for _, c := range Changes(ops, variants) {
+		got = append(got, c.From+">"+c.To+" "+c.Change+" "+c.Opcode.Code)
+	}
+	want := []string{
+		"6502>65c02 Added 1A",
+		"6502>65c02 Changed 69",
+		"6502>65816 Added 1A",
+		"6502>65816 Added 02",
+		"65c02>65816 Added 02",
+		"65c02>65816 Changed 69",
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got %q want %q", got, want)
+	}
+}
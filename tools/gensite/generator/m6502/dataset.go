@@ -0,0 +1,13 @@
+package m6502
+
+import (
+	"context"
+	"github.com/peter-mount/documentation/tools/gensite/generator"
+	"github.com/peter-mount/documentation/tools/gensite/generator/assembly"
+	util2 "github.com/peter-mount/go-kernel/v2/util"
+)
+
+func (s *M6502) writeDataset(ctx context.Context) error {
+	book := generator.GetBook(ctx)
+	return assembly.WriteDataset(ctx, book, util2.NewIterator(s.opcodes(book)...))
+}
@@ -12,11 +12,17 @@ func (s *M6502) writeOpsHexGrid(ctx context.Context) error {
 	book := generator.GetBook(ctx)
 	inst := s.Instructions(book)
 
-	return util.ReferenceFileBuilder("Opcode Matrix", "Instructions shown in an Opcode Matrix", "manual", 10, book.Modified()).
+	return util.ReferenceFileBuilder(
+		book.I18n("reference_hexgrid_title", "Opcode Matrix"),
+		book.I18n("reference_hexgrid_desc", "Instructions shown in an Opcode Matrix"),
+		"manual",
+		10,
+		book.Modified(),
+	).
 		Then(NewHexGrid().
 			OpcodeFrom(inst.Iterator()).
 			FileBuilder()).
 		WrapAsFrontMatter().
 		FileHandler().
-		Write(util.ReferenceFilename(book.ContentPath(), "hexgrid", "_index.html"), book.Modified())
+		Write(ctx, util.ReferenceFilename(book.ContentPath(), "hexgrid", book.PageName("_index.html")), book.Modified())
 }
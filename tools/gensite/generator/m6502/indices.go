@@ -27,7 +27,7 @@ func (s *M6502) writeOpsIndex(ctx context.Context) error {
 		Header:    nil,
 		Body:      m6502IndexBody(inst),
 	}
-	return gen.WriteFile(book, inst.Iterator())
+	return gen.WriteFile(ctx, book, inst.Iterator())
 }
 
 func (s *M6502) writeOpsHexIndex(ctx context.Context) error {
@@ -52,7 +52,7 @@ func (s *M6502) writeOpsHexIndex(ctx context.Context) error {
 		Header:    nil,
 		Body:      m6502IndexBody(inst),
 	}
-	return gen.WriteFile(book, inst.Iterator())
+	return gen.WriteFile(ctx, book, inst.Iterator())
 }
 
 // m6502IndexBody shared
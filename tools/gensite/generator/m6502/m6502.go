@@ -5,36 +5,68 @@ import (
 	"github.com/peter-mount/documentation/tools/gensite/generator/assembly"
 	"github.com/peter-mount/documentation/tools/gensite/generator/autodoc"
 	"github.com/peter-mount/documentation/tools/gensite/hugo"
+	"github.com/peter-mount/documentation/tools/gensite/util/schema"
 	util2 "github.com/peter-mount/go-kernel/v2/util"
 	"github.com/peter-mount/go-kernel/v2/util/task"
 )
 
+const (
+	OpcodesStage = "6502.opcodes" // Opcodes have been extracted from a book
+)
+
 type M6502 struct {
 	generator    *generator.Generator              `kernel:"inject"` // Generator
 	excel        *generator.Excel                  `kernel:"inject"` // Excel
 	autodoc      *autodoc.Autodoc                  `kernel:"inject"` // ResourceManager
+	index        *hugo.ContentIndex                `kernel:"inject"` // ContentIndex
 	extracted    util2.Set[string]                 // Set of book ID's so that we run once per book
 	instructions util2.Map[*assembly.Instructions] // Map of extracted data
 }
 
 func (s *M6502) Start() error {
-	s.extracted = util2.NewHashSet[string]()
+	s.extracted = util2.NewSyncSet[string]()
 	s.instructions = util2.NewSyncMap[*assembly.Instructions]()
 
 	s.generator.
+		Register("6502Opcodes",
+			task.Of(s.extractOpcodes),
+			generator.Provides(OpcodesStage),
+			generator.Translated(),
+			generator.Validates("op", schema.String()),
+			generator.Validates("codes", assembly.Schema())).
 		Register("6502OpsIndex",
-			task.Of().
-				Then(s.extractOpcodes).
-				Then(assembly.DelayOpTask(s.writeOpsIndex))).
+			task.Of(s.writeOpsIndex),
+			generator.Needs(OpcodesStage),
+			generator.Translated()).
 		Register("6502OpsHexIndex",
-			task.Of().
-				Then(s.extractOpcodes).
-				Then(assembly.DelayOpTask(s.writeOpsHexIndex))).
+			task.Of(s.writeOpsHexIndex),
+			generator.Needs(OpcodesStage),
+			generator.Translated()).
 		Register("6502OpsHexGrid",
-			task.Of().
-				Then(s.extractOpcodes).
-				Then(assembly.DelayOpTask(s.writeOpsHexGrid)))
+			task.Of(s.writeOpsHexGrid),
+			generator.Needs(OpcodesStage),
+			generator.Translated()).
+		Register("6502Compatibility",
+			task.Of(s.writeCompatibility).
+				Then(s.writeVariants),
+			generator.Needs(OpcodesStage),
+			generator.Translated()).
+		Register("6502CompatibilityTable",
+			task.Of(s.writeCompatibilityTable).
+				Then(s.writeVariantsTable),
+			generator.Needs(OpcodesStage)).
+		Register("6502Dataset",
+			task.Of(s.writeDataset),
+			generator.Needs(OpcodesStage)).
+		OnReset(s.reset)
+
+	return nil
+}
 
+// reset discards the opcodes extracted from a book so that it can be regenerated
+func (s *M6502) reset(book *hugo.Book) error {
+	s.extracted.Remove(book.ID)
+	s.instructions.Remove(book.ID)
 	return nil
 }
 
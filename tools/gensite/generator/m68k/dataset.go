@@ -0,0 +1,16 @@
+package m68k
+
+import (
+	"context"
+	"github.com/peter-mount/documentation/tools/gensite/generator"
+	"github.com/peter-mount/documentation/tools/gensite/generator/assembly"
+)
+
+func (s *M68k) writeDataset(ctx context.Context) error {
+	book := generator.GetBook(ctx)
+	inst := s.Instructions(book).
+		Sort(func(a *assembly.Opcode, b *assembly.Opcode) bool {
+			return a.Order < b.Order
+		})
+	return assembly.WriteDataset(ctx, book, inst.Iterator())
+}
@@ -0,0 +1,207 @@
+package m68k
+
+import (
+	"context"
+	"github.com/peter-mount/documentation/tools/gensite/generator"
+	"github.com/peter-mount/documentation/tools/gensite/generator/assembly"
+	"github.com/peter-mount/documentation/tools/gensite/hugo"
+	"github.com/peter-mount/go-kernel/v2/util"
+	strings2 "github.com/peter-mount/go-kernel/v2/util/strings"
+	"strings"
+	"sync"
+)
+
+const (
+	EAShortcode = "m68k/effectiveAddress" // Shortcode listing the legal addressing modes on an instruction's page
+)
+
+// EAMode is one of the 12 effective addressing modes, as shown by the theme's m68k/effectiveAddress shortcode
+type EAMode struct {
+	Key   string // Key used by the m68k/effectiveAddress shortcode
+	Label string // Label in html
+}
+
+var EAModes = []EAMode{
+	{"dn", "D<sub>n</sub>"},
+	{"an", "A<sub>n</sub>"},
+	{"(an)", "(A<sub>n</sub>)"},
+	{"(an)+", "(A<sub>n</sub>)+"},
+	{"-(an)", "-(A<sub>n</sub>)"},
+	{"(dan)", "(D<sub>16</sub>,A<sub>n</sub>)"},
+	{"(danxn)", "(D<sub>8</sub>,A<sub>n</sub>,X<sub>n</sub>)"},
+	{"xxw", "(<em>xxx</em>).W"},
+	{"xxl", "(<em>xxx</em>).L"},
+	{"data", "#<em>&lt;data&gt;</em>"},
+	{"(dpc)", "(D<sub>16</sub>,PC)"},
+	{"(dpcxn)", "(D<sub>8</sub>,PC,X<sub>n</sub>)"},
+}
+
+// EARow is a row of the effective address matrix, the addressing modes legal for an operand of an instruction
+type EARow struct {
+	Op      string // Instruction
+	Operand string // "source" or "destination" when the instruction's page lists the modes for each, otherwise ""
+	Modes   []bool // Legal modes, in the same order as EAModes
+}
+
+// EffectiveAddresses holds the EARows of the instructions in a book, from the EAShortcode's on their pages
+type EffectiveAddresses struct {
+	mutex sync.Mutex
+	rows  map[string][]*EARow // Rows by instruction
+}
+
+// ShortcodeModes returns which of EAModes are legal in an EAShortcode, those given a value, e.g. "(an)"="1"
+func ShortcodeModes(sc *hugo.Shortcode) []bool {
+	r := make([]bool, len(EAModes))
+	for i, m := range EAModes {
+		r[i] = sc.Params[m.Key] != ""
+	}
+	return r
+}
+
+// ShortcodeRows returns the EARows of an instruction from the EAShortcode's on it's page.
+// A page with two of them lists the modes of the source operand then those of the destination.
+func ShortcodeRows(op string, shortcodes []*hugo.Shortcode) []*EARow {
+	var a []*hugo.Shortcode
+	for _, sc := range shortcodes {
+		if sc.Name == EAShortcode {
+			a = append(a, sc)
+		}
+	}
+
+	var r []*EARow
+	for i, sc := range a {
+		row := &EARow{Op: op, Modes: ShortcodeModes(sc)}
+		if len(a) == 2 {
+			row.Operand = []string{"source", "destination"}[i]
+		}
+		r = append(r, row)
+	}
+	return r
+}
+
+// ExtractFrontMatter records the EARows of each instruction in the codes front matter of a page
+func (e *EffectiveAddresses) ExtractFrontMatter(ctx context.Context, fm *hugo.FrontMatter) error {
+	body := hugo.PageBody(ctx)
+	if body == nil {
+		return nil
+	}
+
+	defaultOp := util.DecodeString(fm.Other["op"], "")
+	return util.ForEachInterface(ctx.Value("other"), func(e1 interface{}) error {
+		return util.IfMap(e1, func(m map[interface{}]interface{}) error {
+			op := util.DecodeString(m["op"], defaultOp)
+			if rows := ShortcodeRows(op, body.Shortcodes()); len(rows) > 0 {
+				e.add(op, rows)
+			}
+			return nil
+		})
+	})
+}
+
+func (e *EffectiveAddresses) add(op string, rows []*EARow) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if e.rows == nil {
+		e.rows = make(map[string][]*EARow)
+	}
+	if _, exists := e.rows[op]; !exists {
+		e.rows[op] = rows
+	}
+}
+
+// Rows returns the EARows of an opcode, from the EAShortcode's on it's page.
+// If there are none they are derived from it's format, see EffectiveAddressModes.
+func (e *EffectiveAddresses) Rows(op *assembly.Opcode) []*EARow {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if rows, exists := e.rows[op.Op]; exists {
+		return rows
+	}
+	return []*EARow{{Op: op.Op, Modes: EffectiveAddressModes(op.Format)}}
+}
+
+// EffectiveAddressModes returns which of EAModes are legal for an instruction, derived from its format.
+// This is only used when the instruction's page has no EAShortcode:
+//
+//	E an effective address field, so any mode
+//	M an R/M field, so either data registers or address registers with predecrement, e.g. ABCD & ADDX
+//	R, r or d a register field, so data registers
+func EffectiveAddressModes(format string) []bool {
+	r := make([]bool, len(EAModes))
+	set := func(keys ...string) {
+		for i, m := range EAModes {
+			for _, k := range keys {
+				if m.Key == k {
+					r[i] = true
+				}
+			}
+		}
+	}
+
+	switch {
+	case strings.ContainsRune(format, 'E'):
+		for i := range r {
+			r[i] = true
+		}
+	case strings.ContainsRune(format, 'M'):
+		set("dn", "-(an)")
+	case strings.ContainsAny(format, "Rrd"):
+		set("dn")
+	}
+	return r
+}
+
+// eaEntry is a row of the effective address matrix
+type eaEntry struct {
+	op  *assembly.Opcode
+	row *EARow
+}
+
+func (s *M68k) writeEAMatrix(ctx context.Context) error {
+	book := generator.GetBook(ctx)
+	inst := s.Instructions(book).
+		Sort(func(a *assembly.Opcode, b *assembly.Opcode) bool {
+			return a.Op < b.Op
+		})
+	addresses := s.EffectiveAddresses(book)
+
+	// The rows from a page are shown once, even if it has more than one opcode for an instruction
+	var entries []*eaEntry
+	seen := make(map[*EARow]bool)
+	inst.Iterator().ForEach(func(op *assembly.Opcode) {
+		for _, row := range addresses.Rows(op) {
+			if !seen[row] {
+				seen[row] = true
+				entries = append(entries, &eaEntry{op: op, row: row})
+			}
+		}
+	})
+
+	gen := &assembly.IndexGenerator{
+		Prefix: "codes",
+		Name:   "effectiveaddress",
+		Title:  "Effective Address Matrix",
+		Desc:   "Effective addressing modes by instruction",
+		Class:  "opIndex1",
+		Header: func(slice strings2.StringSlice, _ int) strings2.StringSlice {
+			slice = append(slice, "<thead><tr>", "<th>Instruction</th>", "<th>Operand</th>")
+			for _, m := range EAModes {
+				slice = append(slice, "<th>"+m.Label+"</th>")
+			}
+			return append(slice, "</tr></thead>")
+		},
+		Body: func(slice strings2.StringSlice, _ int, entry interface{}) strings2.StringSlice {
+			e := entry.(*eaEntry)
+			row := "<tr><td>" + inst.OpcodeFormatter(e.op) + "</td><td>" + e.row.Operand + "</td>"
+			for _, legal := range e.row.Modes {
+				if legal {
+					row = row + "<td>&check;</td>"
+				} else {
+					row = row + "<td>&hyphen;</td>"
+				}
+			}
+			return append(slice, row+"</tr>")
+		},
+	}
+	return gen.Write(ctx, book, assembly.Iterator(entries))
+}
@@ -0,0 +1,52 @@
+package m68k
+
+import (
+	"github.com/peter-mount/documentation/tools/gensite/hugo"
+	"strings"
+	"testing"
+)
+
+func TestEffectiveAddressModes(t *testing.T) {
+	count := func(modes []bool) (n int) {
+		for _, m := range modes {
+			if m {
+				n++
+			}
+		}
+		return
+	}
+
+	for format, want := range map[string]int{"1101dmE": 12, "1101R1S00Mr": 2, "0111R0dddddddd": 1, "0100111001110001": 0} {
+		if got := count(EffectiveAddressModes(format)); got != want {
+			t.Errorf("%s got %d modes want %d", format, got, want)
+		}
+	}
+}
+
+func TestShortcodeRows(t *testing.T) {
+	rows := ShortcodeRows("ADD", []*hugo.Shortcode{
+		{Name: "m68k/instructionFields"},
+		{Name: EAShortcode, Params: map[string]string{"dn": "1", "an": "1", "note_an": "Word and long only"}},
+		{Name: EAShortcode, Params: map[string]string{"(an)": "1", "xxl": "1"}},
+	})
+
+	var got []string
+	for _, r := range rows {
+		var keys []string
+		for i, legal := range r.Modes {
+			if legal {
+				keys = append(keys, EAModes[i].Key)
+			}
+		}
+		got = append(got, r.Op+" "+r.Operand+" "+strings.Join(keys, ","))
+	}
+
+	want := []string{"ADD source dn,an", "ADD destination (an),xxl"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if rows := ShortcodeRows("ABCD", nil); len(rows) != 0 {
+		t.Errorf("expected no rows got %d", len(rows))
+	}
+}
@@ -0,0 +1,154 @@
+package m68k
+
+import (
+	"context"
+	"fmt"
+	"github.com/peter-mount/documentation/tools/gensite/generator"
+	"github.com/peter-mount/documentation/tools/gensite/generator/assembly"
+	"github.com/peter-mount/documentation/tools/gensite/util"
+	strings2 "github.com/peter-mount/go-kernel/v2/util/strings"
+	"html"
+	"strings"
+)
+
+// Lines are the instruction families of the 68000 opcode map, indexed by the top 4 bits of an opcode, the "line"
+var Lines = [16]string{
+	"Bit manipulation, MOVEP & immediate",
+	"Move byte",
+	"Move long",
+	"Move word",
+	"Miscellaneous",
+	"ADDQ, SUBQ, Scc & DBcc",
+	"Bcc, BSR & BRA",
+	"MOVEQ",
+	"OR, DIV & SBCD",
+	"SUB & SUBX",
+	"Unassigned, reserved (Line A)",
+	"CMP & EOR",
+	"AND, MUL, ABCD & EXG",
+	"ADD & ADDX",
+	"Shift, rotate & bit field",
+	"Coprocessor interface (Line F)",
+}
+
+// HexMap is the opcode matrix of the top 8 bits of an opcode, the row being the line & the column the next 4 bits
+type HexMap [16][16]*HexCell
+
+// HexCell is a cell in the matrix, in the format used by the theme's 6502/hexMap partial
+type HexCell struct {
+	Label  string   `yaml:"label"`            // Instructions whose opcode can start with this byte, blank if unused
+	Colour string   `yaml:"colour,omitempty"` // Colour
+	ops    []string `yaml:"-"`                // Instructions in this cell
+}
+
+// Output is used for generating the front matter of the opcode matrix page
+type Output struct {
+	HexGrid map[string]*HexMap `yaml:"hexGrid"`
+}
+
+// NewHexMap returns the HexMap of some instructions.
+// As 68000 opcodes are bit patterns an instruction will usually appear in more than one cell.
+func NewHexMap(inst []*assembly.Opcode) *HexMap {
+	m := &HexMap{}
+	for r := range m {
+		for c := range m[r] {
+			m[r][c] = &HexCell{}
+		}
+	}
+
+	for _, op := range inst {
+		if len(op.Code) < 8 {
+			continue
+		}
+		for b := 0; b < 256; b++ {
+			if MatchesByte(op.Code[:8], b) {
+				m[b>>4][b&0xf].add(op)
+			}
+		}
+	}
+
+	return m
+}
+
+// MatchesByte returns true if the bit pattern of the top byte of an opcode, "0", "1" or "X" for each bit, matches b
+func MatchesByte(pattern string, b int) bool {
+	for i, c := range pattern {
+		bit := (b >> (7 - i)) & 1
+		if (c == '0' && bit != 0) || (c == '1' && bit != 1) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *HexCell) add(op *assembly.Opcode) {
+	for _, o := range c.ops {
+		if o == op.Op {
+			return
+		}
+	}
+	c.ops = append(c.ops, op.Op)
+	c.Label = strings.Join(c.ops, " ")
+	if c.Colour == "" {
+		c.Colour = op.Colour
+	}
+}
+
+// Ops returns the instructions in a line of the HexMap
+func (m *HexMap) Ops(line int) []string {
+	var r []string
+	seen := map[string]bool{}
+	for _, c := range m[line] {
+		for _, op := range c.ops {
+			if !seen[op] {
+				seen[op] = true
+				r = append(r, op)
+			}
+		}
+	}
+	return r
+}
+
+func (s *M68k) writeOpsHexGrid(ctx context.Context) error {
+	book := generator.GetBook(ctx)
+	inst := s.Instructions(book)
+
+	var ops []*assembly.Opcode
+	inst.Iterator().ForEach(func(op *assembly.Opcode) {
+		ops = append(ops, op)
+	})
+	m := NewHexMap(ops)
+
+	return util.ReferenceFileBuilder(
+		book.I18n("reference_hexgrid_title", "Opcode Matrix"),
+		book.I18n("reference_hexgrid_desc", "Instructions shown in an Opcode Matrix"),
+		"manual",
+		10,
+		book.Modified(),
+	).
+		Yaml(Output{HexGrid: map[string]*HexMap{"": m}}).
+		WrapAsFrontMatter().
+		Then(m.lineTable()).
+		FileHandler().
+		Write(ctx, util.ReferenceFilename(book.ContentPath(), "hexgrid", book.PageName("_index.html")), book.Modified())
+}
+
+// lineTable lists the instruction family of each line & the instructions documented in it
+func (m *HexMap) lineTable() util.FileBuilder {
+	return func(slice strings2.StringSlice) (strings2.StringSlice, error) {
+		slice = append(slice,
+			"<div class='opIndex1'><table>",
+			"<thead><tr>",
+			"<th>Line</th>",
+			"<th>Bits 15-12</th>",
+			"<th>Instruction family</th>",
+			"<th>Instructions</th>",
+			"</tr></thead>",
+			"<tbody>")
+		for l, family := range Lines {
+			slice = append(slice, fmt.Sprintf("<tr><td>%X</td><td>%04b</td><td>%s</td><td>%s</td></tr>",
+				l, l, html.EscapeString(family), html.EscapeString(strings.Join(m.Ops(l), ", "))))
+		}
+		return append(slice, "</tbody></table></div>"), nil
+	}
+}
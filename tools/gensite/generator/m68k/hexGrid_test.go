@@ -0,0 +1,35 @@
+package m68k
+
+import (
+	"github.com/peter-mount/documentation/tools/gensite/generator/assembly"
+	"testing"
+)
+
+func TestNewHexMap(t *testing.T) {
+	s := &M68k{}
+	op := func(name, format string) *assembly.Opcode {
+		o := &assembly.Opcode{Op: name, Format: format}
+		s.extractFormat(format, o)
+		return o
+	}
+
+	m := NewHexMap([]*assembly.Opcode{
+		op("ADD", "1101dmE"),
+		op("ADDX", "1101R1S00Mr"),
+		op("ABCD", "1100R10000Mr"),
+	})
+
+	for idx, want := range map[int]string{
+		0xD0: "ADD",
+		0xD1: "ADD ADDX",
+		0xDF: "ADD ADDX",
+		0xC0: "",
+		0xC1: "ABCD",
+		0xCF: "ABCD",
+		0x00: "",
+	} {
+		if got := m[idx>>4][idx&0xf].Label; got != want {
+			t.Errorf("%02X got %q want %q", idx, got, want)
+		}
+	}
+}
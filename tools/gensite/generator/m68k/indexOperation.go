@@ -39,5 +39,5 @@ func (s *M68k) writeOperationIndex(ctx context.Context) error {
 			return append(slice, fmt.Sprintf("<tr%s><td>%s</td><td>%s</td></tr>", class, inst.OpcodeFormatter(op), op.Code))
 		},
 	}
-	return gen.WriteFile(book, inst.Iterator())
+	return gen.WriteFile(ctx, book, inst.Iterator())
 }
@@ -5,32 +5,74 @@ import (
 	"github.com/peter-mount/documentation/tools/gensite/generator/assembly"
 	"github.com/peter-mount/documentation/tools/gensite/generator/autodoc"
 	"github.com/peter-mount/documentation/tools/gensite/hugo"
+	"github.com/peter-mount/documentation/tools/gensite/util/schema"
 	"github.com/peter-mount/go-kernel/v2/util"
 	"github.com/peter-mount/go-kernel/v2/util/task"
 )
 
+const (
+	OpcodesStage = "68k.opcodes" // Opcodes have been extracted from a book
+)
+
 type M68k struct {
 	generator    *generator.Generator             `kernel:"inject"` // Generator
 	excel        *generator.Excel                 `kernel:"inject"` // Excel
 	autodoc      *autodoc.Autodoc                 `kernel:"inject"` // ResourceManager
+	index        *hugo.ContentIndex               `kernel:"inject"` // ContentIndex
 	extracted    util.Set[string]                 // Set of book ID's so that we run once per book
 	instructions util.Map[*assembly.Instructions] // Map of extracted data
+	addresses    util.Map[*EffectiveAddresses]    // Effective addresses extracted per book
 }
 
 func (s *M68k) Start() error {
-	s.extracted = util.NewHashSet[string]()
+	s.extracted = util.NewSyncSet[string]()
 	s.instructions = util.NewSyncMap[*assembly.Instructions]()
+	s.addresses = util.NewSyncMap[*EffectiveAddresses]()
 
 	s.generator.
+		Register("68kOpcodes",
+			task.Of(s.extractOpcodes),
+			generator.Provides(OpcodesStage),
+			generator.Translated(),
+			generator.Validates("op", schema.String()),
+			generator.Validates("codes", assembly.Schema())).
 		Register("68kOperationIndex",
 			task.Of().
-				Then(s.extractOpcodes).
-				Then(assembly.DelayOpTask(s.writeOperationIndex)).
-				Then(assembly.DelayOpTask(s.writeOpcodeIndex)))
+				Then(s.writeOperationIndex).
+				Then(s.writeOpcodeIndex),
+			generator.Needs(OpcodesStage),
+			generator.Translated()).
+		Register("68kOpsHexGrid",
+			task.Of(s.writeOpsHexGrid),
+			generator.Needs(OpcodesStage),
+			generator.Translated()).
+		Register("68kEAMatrix",
+			task.Of(s.writeEAMatrix),
+			generator.Needs(OpcodesStage),
+			generator.Translated()).
+		Register("68kDataset",
+			task.Of(s.writeDataset),
+			generator.Needs(OpcodesStage)).
+		OnReset(s.reset)
 
 	return nil
 }
 
+// reset discards the opcodes extracted from a book so that it can be regenerated
+func (s *M68k) reset(book *hugo.Book) error {
+	s.extracted.Remove(book.ID)
+	s.instructions.Remove(book.ID)
+	s.addresses.Remove(book.ID)
+	return nil
+}
+
 func (s *M68k) Instructions(b *hugo.Book) *assembly.Instructions {
 	return s.instructions.ComputeIfAbsent(b.ID, assembly.ComputeNewInstructions)
 }
+
+// EffectiveAddresses returns the EffectiveAddresses extracted for a Book
+func (s *M68k) EffectiveAddresses(b *hugo.Book) *EffectiveAddresses {
+	return s.addresses.ComputeIfAbsent(b.ID, func(_ string) *EffectiveAddresses {
+		return &EffectiveAddresses{}
+	})
+}
@@ -0,0 +1,252 @@
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/peter-mount/documentation/tools/gensite"
+	"github.com/peter-mount/documentation/tools/gensite/util"
+	"github.com/peter-mount/go-kernel/v2/log"
+	"github.com/peter-mount/go-kernel/v2/util/task"
+	"os"
+	"path"
+	"sort"
+	"sync"
+)
+
+const (
+	ManifestFile = "public/static/gen/manifest.json" // Location of the build manifest
+)
+
+// Manifest is a kernel Service which records every file generated, what produced it & from which pages.
+// Once everything has been generated it is written to ManifestFile.
+//
+// Files in the previous manifest which were not generated this time, but are still present, are kept in the
+// manifest marked as stale so that they can be removed later, see Prune.
+type Manifest struct {
+	dryRun   *util.DryRun   `kernel:"inject"` // DryRun
+	validate *util.Validate `kernel:"inject"` // Validate
+	worker   task.Queue     `kernel:"worker"` // Worker queue
+	mutex    sync.Mutex
+	entries  map[string]*ManifestEntry // Entries by path
+	claimed  map[string]string         // Files to be written later in the run, mapped to the ID of their book
+	previous []*ManifestEntry          // Entries in the previous manifest
+	hashes   map[string]string         // Hash of each file in the previous manifest
+}
+
+// ManifestEntry describes a generated file
+type ManifestEntry struct {
+	Path      string   `json:"path"`                // Path of the file
+	Generator string   `json:"generator,omitempty"` // Generator which produced the file
+	Book      string   `json:"book,omitempty"`      // ID of the book the file was produced for
+	Size      int64    `json:"size"`                // Size of the file in bytes
+	Hash      string   `json:"hash"`                // sha256 hash of the file
+	Sources   []string `json:"sources,omitempty"`   // Pages used to produce the file
+	Stale     bool     `json:"stale,omitempty"`     // true if no longer generated
+}
+
+// manifestFile is the format of ManifestFile
+type manifestFile struct {
+	Files []*ManifestEntry `json:"files"`
+}
+
+func (m *Manifest) Start() error {
+	m.entries = make(map[string]*ManifestEntry)
+	m.claimed = make(map[string]string)
+
+	// Load now as -clean will remove it
+	previous, err := loadManifest()
+	if err != nil {
+		log.Printf("Ignoring %s: %v", ManifestFile, err)
+	}
+	m.setPrevious(previous)
+
+	util.AddWriteListener(m.record)
+	util.SetHashLookup(m.previousHash)
+
+	m.worker.AddPriorityTask(tools.PriorityManifest, m.save)
+	return nil
+}
+
+// record adds a file which has just been written to the manifest
+func (m *Manifest) record(ctx context.Context, fileName string, _ bool) {
+	e := &ManifestEntry{Path: fileName}
+
+	if p := util.GetProducer(ctx); p != nil {
+		e.Generator = p.Generator
+		e.Book = p.Book
+		e.Sources = p.Sources()
+	}
+
+	// Use util.ReadFile as in dry-run mode the file will not be on disk
+	if buf, err := util.ReadFile(fileName); err == nil {
+		e.Size = int64(len(buf))
+		e.Hash = util.Hash(buf)
+	} else {
+		log.Printf("Manifest %s: %v", fileName, err)
+	}
+
+	m.Add(e)
+}
+
+// Add adds entries to the manifest, replacing any existing entry for the same path
+func (m *Manifest) Add(entries ...*ManifestEntry) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for _, e := range entries {
+		m.entries[e.Path] = e
+		m.hashes[e.Path] = e.Hash
+	}
+}
+
+// Claim records that a file will be written later in the run, e.g. a workbook which is written once all of its
+// sheets are known. Until then it is not in the manifest but must not be seen as stale, see Prune.
+func (m *Manifest) Claim(ctx context.Context, fileName string) {
+	book := ""
+	if p := util.GetProducer(ctx); p != nil {
+		book = p.Book
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.claimed[fileName] = book
+}
+
+// RemoveBook removes the entries & claims for the files produced for a book, e.g. before it is regenerated
+func (m *Manifest) RemoveBook(id string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for k, e := range m.entries {
+		if e.Book == id {
+			delete(m.entries, k)
+		}
+	}
+
+	for k, book := range m.claimed {
+		if book == id {
+			delete(m.claimed, k)
+		}
+	}
+}
+
+// Book returns the entries for files produced for a book, sorted by path
+func (m *Manifest) Book(id string) []*ManifestEntry {
+	return m.filter(func(e *ManifestEntry) bool {
+		return e.Book == id
+	})
+}
+
+// Entries returns all entries, sorted by path
+func (m *Manifest) Entries() []*ManifestEntry {
+	return m.filter(func(_ *ManifestEntry) bool {
+		return true
+	})
+}
+
+func (m *Manifest) filter(f func(*ManifestEntry) bool) []*ManifestEntry {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var a []*ManifestEntry
+	for _, e := range m.entries {
+		if f(e) {
+			a = append(a, e)
+		}
+	}
+
+	sort.Slice(a, func(i, j int) bool {
+		return a[i].Path < a[j].Path
+	})
+	return a
+}
+
+// Stale returns the entries in the previous manifest for files which still exist but have not been written or
+// claimed during this run, sorted by path.
+func (m *Manifest) Stale() []*ManifestEntry {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var a []*ManifestEntry
+	for _, e := range m.previous {
+		_, written := m.entries[e.Path]
+		_, claimed := m.claimed[e.Path]
+		if written || claimed {
+			continue
+		}
+
+		if _, err := os.Stat(e.Path); err == nil {
+			s := *e
+			s.Stale = true
+			a = append(a, &s)
+		}
+	}
+	return a
+}
+
+// setPrevious sets the entries of the previous manifest
+func (m *Manifest) setPrevious(entries []*ManifestEntry) {
+	hashes := make(map[string]string)
+	for _, e := range entries {
+		if !e.Stale {
+			hashes[e.Path] = e.Hash
+		}
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.previous = entries
+	m.hashes = hashes
+}
+
+// previousHash returns the hash of a file when it was last generated, so FileHandler.Write can tell if it has changed
+func (m *Manifest) previousHash(fileName string) (string, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	h, exists := m.hashes[fileName]
+	return h, exists
+}
+
+// loadManifest returns the entries in a previously written manifest, nil if there is none
+func loadManifest() ([]*ManifestEntry, error) {
+	buf, err := os.ReadFile(ManifestFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	f := &manifestFile{}
+	if err := json.Unmarshal(buf, f); err != nil {
+		return nil, err
+	}
+	return f.Files, nil
+}
+
+// save writes the manifest
+func (m *Manifest) save(_ context.Context) error {
+	// Nothing has been written in dry-run or validate mode
+	if m.dryRun.Enabled() || m.validate.Enabled() {
+		return nil
+	}
+
+	files := append(m.Entries(), m.Stale()...)
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+
+	buf, err := json.MarshalIndent(&manifestFile{Files: files}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// In watch mode the next save is against what we have now
+	m.setPrevious(files)
+
+	// Write directly rather than with a FileHandler, so the manifest does not include itself
+	log.Printf("Writing %s", ManifestFile)
+	if err = os.MkdirAll(path.Dir(ManifestFile), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(ManifestFile, buf, 0644)
+}
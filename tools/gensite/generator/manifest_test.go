@@ -0,0 +1,26 @@
+package generator
+
+import (
+	"testing"
+)
+
+func TestManifest_previousHash(t *testing.T) {
+	m := &Manifest{entries: make(map[string]*ManifestEntry)}
+	m.setPrevious([]*ManifestEntry{
+		{Path: "content/a/_index.html", Hash: "a"},
+		{Path: "content/b/_index.html", Hash: "b", Stale: true},
+	})
+	m.Add(&ManifestEntry{Path: "content/c/_index.html", Hash: "c"})
+
+	for fileName, want := range map[string]string{
+		"content/a/_index.html": "a",
+		"content/b/_index.html": "",
+		"content/c/_index.html": "c",
+		"content/d/_index.html": "",
+	} {
+		got, exists := m.previousHash(fileName)
+		if got != want || exists != (want != "") {
+			t.Errorf("previousHash(%q) got %q,%v want %q", fileName, got, exists, want)
+		}
+	}
+}
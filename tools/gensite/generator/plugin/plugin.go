@@ -0,0 +1,224 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/peter-mount/documentation/tools/gensite/generator"
+	"github.com/peter-mount/documentation/tools/gensite/hugo"
+	"github.com/peter-mount/documentation/tools/gensite/util"
+	"github.com/peter-mount/go-kernel/v2/log"
+	"github.com/peter-mount/go-kernel/v2/util/task"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"time"
+)
+
+// Plugins runs external generators declared in the generators section of config.yaml, e.g.
+//
+//	generators:
+//	  sidRegisters:
+//	    command: "tools/sid/registers.py"
+//	    args: [ "--chip", "6581" ]
+//
+// Each is registered under its name, so a book can list it in generate like any other generator.
+//
+// The command receives the book & the front matter of each of its pages as JSON on stdin, see Input, and writes
+// the files to generate as JSON to stdout, see Output. Anything written to stderr is passed through.
+//
+// As the build cache does not know about the command, use -force after changing it.
+type Plugins struct {
+	generator *generator.Generator `kernel:"inject"`            // Generator
+	index     *hugo.ContentIndex   `kernel:"inject"`            // ContentIndex
+	config    *Config              `kernel:"config,generators"` // Plugin definitions
+}
+
+// Config is the generators section of config.yaml
+type Config struct {
+	Plugins map[string]*Plugin `yaml:",inline"` // Plugins by generator name
+}
+
+// Plugin defines an external generator
+type Plugin struct {
+	Command string             `yaml:"command"` // Command to run
+	Args    []string           `yaml:"args"`    // Arguments to pass to the command
+	name    string             // Name registered with the Generator
+	index   *hugo.ContentIndex // ContentIndex to read the book's pages from
+}
+
+// Input is written to the plugin's stdin
+type Input struct {
+	Book  *Book   `json:"book"`  // The book being generated
+	Pages []*Page `json:"pages"` // Pages in the book, sorted by path
+}
+
+// Book describes the book being generated
+type Book struct {
+	ID          string    `json:"id"`          // ID of the book
+	Title       string    `json:"title"`       // Title of the book
+	ContentPath string    `json:"contentPath"` // Path to the book's content
+	Modified    time.Time `json:"modified"`    // Last modified time of the book's content
+}
+
+// Page is the front matter of a page
+type Page struct {
+	Path        string                 `json:"path"`        // Path of the page
+	FrontMatter map[string]interface{} `json:"frontMatter"` // The page's front matter
+}
+
+// Output is read from the plugin's stdout
+type Output struct {
+	Files []*File `json:"files"` // Files to write
+}
+
+// File is a file to be written by a plugin
+type File struct {
+	Path    string `json:"path"`    // Path of the file, relative to the site & within a generated directory
+	Content string `json:"content"` // Content of the file
+}
+
+func (p *Plugins) Start() error {
+	var names []string
+	for name := range p.config.Plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		plugin := p.config.Plugins[name]
+		if plugin == nil || plugin.Command == "" {
+			return fmt.Errorf("plugin %s has no command", name)
+		}
+
+		if p.generator.Registered(name) {
+			return fmt.Errorf("plugin %s has the same name as a generator", name)
+		}
+
+		plugin.name = name
+		plugin.index = p.index
+		p.generator.Register(name, task.Of(plugin.run))
+	}
+
+	return nil
+}
+
+// run runs the plugin against the book in the context & writes the files it returns
+func (p *Plugin) run(ctx context.Context) error {
+	book := generator.GetBook(ctx)
+
+	input, err := p.input(ctx, book)
+	if err != nil {
+		return err
+	}
+
+	buf, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Running plugin %s on %s", p.name, book.ID)
+
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.Command, p.Args...)
+	cmd.Stdin = bytes.NewReader(buf)
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("plugin %s: %w", p.name, err)
+	}
+
+	output := &Output{}
+	if err := json.Unmarshal(stdout.Bytes(), output); err != nil {
+		return fmt.Errorf("plugin %s: invalid output: %w", p.name, err)
+	}
+
+	for _, f := range output.Files {
+		fileName := path.Clean(f.Path)
+		if fileName != f.Path || !generator.GeneratedPath(fileName) {
+			return fmt.Errorf("plugin %s: cannot write %q as it is not within a generated directory", p.name, f.Path)
+		}
+
+		if err := util.StringFileHandler(f.Content).Write(ctx, fileName, book.Modified()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// input returns the Input for a book. Every page is recorded as a source of the files the plugin writes.
+func (p *Plugin) input(ctx context.Context, book *hugo.Book) (*Input, error) {
+	input := &Input{
+		Book: &Book{
+			ID:          book.ID,
+			Title:       book.Title,
+			ContentPath: book.ContentPath(),
+			Modified:    book.Modified(),
+		},
+	}
+
+	err := p.index.Walk(ctx, book, hugo.FrontMatterActionOf(func(ctx context.Context, fm *hugo.FrontMatter) error {
+		fileName := hugo.FilePath(ctx)
+		util.GetProducer(ctx).AddSource(fileName)
+		input.Pages = append(input.Pages, &Page{Path: fileName, FrontMatter: frontMatter(fm)})
+		return nil
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(input.Pages, func(i, j int) bool {
+		return input.Pages[i].Path < input.Pages[j].Path
+	})
+
+	return input, nil
+}
+
+// frontMatter returns a page's front matter in a form which can be marshaled as JSON
+func frontMatter(fm *hugo.FrontMatter) map[string]interface{} {
+	m := make(map[string]interface{})
+	for k, v := range fm.Other {
+		m[k] = jsonValue(v)
+	}
+
+	set := func(k string, v interface{}, empty bool) {
+		if !empty {
+			m[k] = v
+		}
+	}
+	set("type", fm.Type, fm.Type == "")
+	set("title", fm.Title, fm.Title == "")
+	set("linkTitle", fm.LinkTitle, fm.LinkTitle == "")
+	set("weight", fm.Weight, fm.Weight == 0)
+	set("categories", fm.Categories, len(fm.Categories) == 0)
+	set("tags", fm.Tags, len(fm.Tags) == 0)
+	set("draft", fm.Draft, !fm.Draft)
+	set("date", fm.Date.Time, fm.Date.IsZero())
+	set("publishDate", fm.PublishDate.Time, fm.PublishDate.IsZero())
+	set("expiryDate", fm.ExpiryDate.Time, fm.ExpiryDate.IsZero())
+
+	return m
+}
+
+// jsonValue converts the maps yaml decodes into, which have interface{} keys, into ones with string keys
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{})
+		for k, e := range v {
+			m[fmt.Sprint(k)] = jsonValue(e)
+		}
+		return m
+	case []interface{}:
+		a := make([]interface{}, len(v))
+		for i, e := range v {
+			a[i] = jsonValue(e)
+		}
+		return a
+	default:
+		return v
+	}
+}
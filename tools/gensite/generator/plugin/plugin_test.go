@@ -0,0 +1,24 @@
+package plugin
+
+import (
+	"encoding/json"
+	"github.com/peter-mount/documentation/tools/gensite/hugo"
+	"gopkg.in/yaml.v2"
+	"testing"
+)
+
+func TestFrontMatter(t *testing.T) {
+	fm := &hugo.FrontMatter{}
+	if err := yaml.Unmarshal([]byte("title: SID\nweight: 10\nregisters:\n  - addr: 0xD400\n    name: FRELO1\n"), fm); err != nil {
+		t.Fatal(err)
+	}
+
+	buf, err := json.Marshal(frontMatter(fm))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := string(buf), `{"registers":[{"addr":54272,"name":"FRELO1"}],"title":"SID","weight":10}`; got != want {
+		t.Errorf("got %s want %s", got, want)
+	}
+}
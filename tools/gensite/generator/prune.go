@@ -0,0 +1,139 @@
+package generator
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/peter-mount/documentation/tools/gensite"
+	"github.com/peter-mount/documentation/tools/gensite/util"
+	"github.com/peter-mount/go-kernel/v2"
+	"github.com/peter-mount/go-kernel/v2/log"
+	"github.com/peter-mount/go-kernel/v2/util/task"
+	"os"
+	"path"
+	"strings"
+)
+
+// Prune is a kernel Service which removes generated files which nothing generated this time, e.g. the reference page
+// of an opcode whose page has been deleted.
+//
+// Only stale files in the Manifest are candidates, so nothing is pruned until a manifest has been written.
+// Files outside the directories gensite generates into, or which have been modified since they were generated,
+// are never removed.
+type Prune struct {
+	manifest *Manifest      `kernel:"inject"` // Manifest
+	dryRun   *util.DryRun   `kernel:"inject"` // DryRun
+	validate *util.Validate `kernel:"inject"` // Validate
+	worker   task.Queue     `kernel:"worker"` // Worker queue
+	mode     pruneMode      // -prune flag
+}
+
+// pruneMode implements flag.Value so that both -prune & -prune=dry can be used
+type pruneMode string
+
+const (
+	pruneOff    pruneMode = ""     // Do not prune
+	pruneRemove pruneMode = "true" // Remove stale files
+	pruneDry    pruneMode = "dry"  // List stale files
+)
+
+func (m *pruneMode) String() string {
+	return string(*m)
+}
+
+func (m *pruneMode) Set(s string) error {
+	switch pruneMode(s) {
+	case pruneRemove, pruneDry:
+		*m = pruneMode(s)
+	case "false":
+		*m = pruneOff
+	default:
+		return fmt.Errorf("invalid prune mode %q", s)
+	}
+	return nil
+}
+
+func (m *pruneMode) IsBoolFlag() bool {
+	return true
+}
+
+func (p *Prune) Init(_ *kernel.Kernel) error {
+	flag.Var(&p.mode, "prune", "Remove generated files which are no longer generated or list them with -prune=dry")
+	return nil
+}
+
+func (p *Prune) Start() error {
+	// Nothing is generated in validate mode so everything would be stale
+	if p.mode == pruneOff || p.validate.Enabled() {
+		return nil
+	}
+
+	p.worker.AddPriorityTask(tools.PriorityPrune, p.prune)
+	return nil
+}
+
+// prune removes, or lists, the stale files in the manifest
+func (p *Prune) prune(_ context.Context) error {
+	if p.mode == pruneOff {
+		return nil
+	}
+
+	// Nothing is removed in dry-run mode
+	dry := p.mode == pruneDry || p.dryRun.Enabled()
+
+	count := 0
+	for _, e := range p.manifest.Stale() {
+		if !GeneratedPath(e.Path) {
+			continue
+		}
+
+		buf, err := os.ReadFile(e.Path)
+		if err != nil {
+			return err
+		}
+
+		if util.Hash(buf) != e.Hash {
+			_, _ = fmt.Fprintf(os.Stderr, "Not pruning %s as it has been modified\n", e.Path)
+			continue
+		}
+
+		count++
+		if dry {
+			fmt.Println(e.Path)
+			continue
+		}
+
+		log.Printf("Pruning %s", e.Path)
+		if err := os.Remove(e.Path); err != nil {
+			return err
+		}
+		removeEmptyDirs(path.Dir(e.Path))
+	}
+
+	if dry {
+		_, _ = fmt.Fprintf(os.Stderr, "%d stale generated files\n", count)
+	}
+	return nil
+}
+
+// GeneratedPath returns true if a file is within one of the directories gensite generates into.
+// Anything else is hand-written & must never be written or removed by a generator.
+func GeneratedPath(fileName string) bool {
+	if strings.HasPrefix(fileName, "content/") {
+		return strings.Contains(fileName, "/reference/")
+	}
+
+	for _, dir := range []string{"static/static/book/", "static/static/chipref/", "static/static/gen/"} {
+		if strings.HasPrefix(fileName, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// removeEmptyDirs removes a directory & its parents whilst they are empty & within a generated directory
+func removeEmptyDirs(dir string) {
+	for GeneratedPath(dir+"/") && os.Remove(dir) == nil {
+		dir = path.Dir(dir)
+	}
+}
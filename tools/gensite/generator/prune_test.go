@@ -0,0 +1,41 @@
+package generator
+
+import (
+	"testing"
+)
+
+func TestGeneratedPath(t *testing.T) {
+	for fileName, want := range map[string]bool{
+		"content/asm/6502/reference/_index.html":          true,
+		"content/asm/6502/reference/opcodes/_index.html":  true,
+		"static/static/chipref/motorola/MC68000.svg":      true,
+		"static/static/book/bbcMos.xlsx":                  true,
+		"static/static/gen/c64/cbmdos-disk.svg":           true,
+		"content/asm/6502/opcodes/bit/and/_index.html":    false,
+		"content/asm/6502/_index.html":                    false,
+		"static/static/cdn/css/katex.min.css":             false,
+		"config/svg/c64/cbmdos-disk.yaml":                 false,
+		"public/asm/6502/reference/opcodes/index.html":    false,
+		"static/static/chipref":                           false,
+		"content/asm/6502/referenced/opcodes/_index.html": false,
+	} {
+		if got := GeneratedPath(fileName); got != want {
+			t.Errorf("GeneratedPath(%q) got %v want %v", fileName, got, want)
+		}
+	}
+}
+
+func TestPrune_mode(t *testing.T) {
+	var m pruneMode
+	for _, s := range []string{"true", "dry", "false"} {
+		if err := m.Set(s); err != nil {
+			t.Errorf("Set(%q) %v", s, err)
+		}
+	}
+	if m != pruneOff {
+		t.Errorf("got %q want off", m)
+	}
+	if err := m.Set("all"); err == nil {
+		t.Error("Set(\"all\") expected error")
+	}
+}
@@ -0,0 +1,260 @@
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/peter-mount/documentation/tools/gensite"
+	"github.com/peter-mount/documentation/tools/gensite/util"
+	"github.com/peter-mount/go-kernel/v2/log"
+	"github.com/peter-mount/go-kernel/v2/util/task"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	ReportFile   = "public/static/gen/report.json" // Location of the -report=json report
+	PhaseExtract = "extract"                       // Generator extracting content from a book, i.e. it provides a stage
+	PhaseWrite   = "write"                         // Generator, or a task it has queued, writing files
+)
+
+// Stats is a kernel Service which times each generator & counts the files it writes or skips as unchanged.
+//
+// Each generator task, and every task it queues with Timed, produces an Event which can be streamed as JSON with -log-json.
+// Once everything has been generated a summary table is shown, and with -report=json written to ReportFile.
+type Stats struct {
+	dryRun   *util.DryRun `kernel:"inject"`                                                // DryRun
+	logJson  *bool        `kernel:"flag,log-json,Log generator events as JSON to stderr"`  // Stream events
+	report   *string      `kernel:"flag,report,Write a report of generator timings: json"` // Report format
+	worker   task.Queue   `kernel:"worker"`                                                // Worker queue
+	mutex    sync.Mutex
+	start    time.Time
+	events   []*Event
+	counters map[statsKey]*statsCounter // Files written by each generator & book
+}
+
+// Event is the result of running a single generator task
+type Event struct {
+	Time      time.Time `json:"time"`            // Time the task started
+	Generator string    `json:"generator"`       // Name of the generator
+	Book      string    `json:"book,omitempty"`  // ID of the book, "" if not for a book
+	Phase     string    `json:"phase"`           // PhaseExtract or PhaseWrite
+	Duration  float64   `json:"duration"`        // Duration in seconds
+	Written   int       `json:"written"`         // Number of files written
+	Skipped   int       `json:"skipped"`         // Number of files skipped as unchanged
+	Error     string    `json:"error,omitempty"` // Error returned by the task
+}
+
+// Summary is the total for a generator run against a book
+type Summary struct {
+	Generator string  `json:"generator"`      // Name of the generator
+	Book      string  `json:"book,omitempty"` // ID of the book, "" if not for a book
+	Extract   float64 `json:"extract"`        // Time spent extracting in seconds
+	Write     float64 `json:"write"`          // Time spent writing in seconds
+	Written   int     `json:"written"`        // Number of files written
+	Skipped   int     `json:"skipped"`        // Number of files skipped as unchanged
+}
+
+// Report is the format of ReportFile
+type Report struct {
+	Duration   float64    `json:"duration"`   // Total duration in seconds
+	Generators []*Summary `json:"generators"` // Summary per generator & book
+	Events     []*Event   `json:"events"`     // Every event
+}
+
+type statsKey struct {
+	generator string
+	book      string
+}
+
+type statsCounter struct {
+	written int
+	skipped int
+}
+
+func (s *Stats) Start() error {
+	switch *s.report {
+	case "", "json":
+	default:
+		return fmt.Errorf("unsupported report format %q", *s.report)
+	}
+
+	s.start = time.Now()
+	s.counters = make(map[statsKey]*statsCounter)
+
+	util.AddWriteListener(s.written)
+
+	s.worker.AddPriorityTask(tools.PriorityReport, s.summary)
+	return nil
+}
+
+// written counts the files written by a Producer
+func (s *Stats) written(ctx context.Context, _ string, written bool) {
+	p := util.GetProducer(ctx)
+	if p == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	k := statsKey{generator: p.Generator, book: p.Book}
+	c, exists := s.counters[k]
+	if !exists {
+		c = &statsCounter{}
+		s.counters[k] = c
+	}
+
+	if written {
+		c.written++
+	} else {
+		c.skipped++
+	}
+}
+
+func (s *Stats) counter(k statsKey) statsCounter {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if c, exists := s.counters[k]; exists {
+		return *c
+	}
+	return statsCounter{}
+}
+
+// Timed returns a task which records an Event against a Producer when run.
+// A task queued by a generator should be timed in PhaseWrite against the generator's Producer, util.GetProducer(ctx).
+func (s *Stats) Timed(p *util.Producer, phase string, t task.Task) task.Task {
+	if s == nil || p == nil {
+		return t
+	}
+
+	return func(ctx context.Context) error {
+		k := statsKey{generator: p.Generator, book: p.Book}
+		before := s.counter(k)
+		start := time.Now()
+
+		err := t.Do(ctx)
+
+		after := s.counter(k)
+		e := &Event{
+			Time:      start,
+			Generator: p.Generator,
+			Book:      p.Book,
+			Phase:     phase,
+			Duration:  time.Since(start).Seconds(),
+			Written:   after.written - before.written,
+			Skipped:   after.skipped - before.skipped,
+		}
+		if err != nil {
+			e.Error = err.Error()
+		}
+		s.add(e)
+
+		return err
+	}
+}
+
+func (s *Stats) add(e *Event) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.events = append(s.events, e)
+
+	if *s.logJson {
+		if buf, err := json.Marshal(e); err == nil {
+			_, _ = fmt.Fprintln(os.Stderr, string(buf))
+		}
+	}
+}
+
+// Report returns the report for everything run so far
+func (s *Stats) Report() *Report {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	summaries := make(map[statsKey]*Summary)
+	for _, e := range s.events {
+		k := statsKey{generator: e.Generator, book: e.Book}
+		sum, exists := summaries[k]
+		if !exists {
+			sum = &Summary{Generator: e.Generator, Book: e.Book}
+			summaries[k] = sum
+		}
+
+		if e.Phase == PhaseExtract {
+			sum.Extract += e.Duration
+		} else {
+			sum.Write += e.Duration
+		}
+	}
+
+	// Include everything written, not just those files written by a timed task
+	for k, c := range s.counters {
+		sum, exists := summaries[k]
+		if !exists {
+			sum = &Summary{Generator: k.generator, Book: k.book}
+			summaries[k] = sum
+		}
+		sum.Written = c.written
+		sum.Skipped = c.skipped
+	}
+
+	r := &Report{
+		Duration: time.Since(s.start).Seconds(),
+		Events:   append([]*Event{}, s.events...),
+	}
+	for _, sum := range summaries {
+		r.Generators = append(r.Generators, sum)
+	}
+
+	sort.Slice(r.Generators, func(i, j int) bool {
+		a, b := r.Generators[i], r.Generators[j]
+		if a.Generator != b.Generator {
+			return a.Generator < b.Generator
+		}
+		return a.Book < b.Book
+	})
+
+	return r
+}
+
+// summary shows the summary table & writes the report
+func (s *Stats) summary(_ context.Context) error {
+	r := s.Report()
+
+	if len(r.Generators) > 0 {
+		w := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "Generator\tBook\tExtract\tWrite\tWritten\tSkipped")
+		for _, sum := range r.Generators {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%.3fs\t%.3fs\t%d\t%d\n",
+				sum.Generator, sum.Book, sum.Extract, sum.Write, sum.Written, sum.Skipped)
+		}
+		_ = w.Flush()
+		_, _ = fmt.Fprintf(os.Stderr, "Total %.3fs\n", r.Duration)
+	}
+
+	if *s.report != "json" {
+		return nil
+	}
+
+	// Nothing is written in dry-run mode
+	if s.dryRun.Enabled() {
+		log.Printf("Not writing %s in dry-run mode", ReportFile)
+		return nil
+	}
+
+	buf, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Writing %s", ReportFile)
+	if err = os.MkdirAll(path.Dir(ReportFile), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(ReportFile, buf, 0644)
+}
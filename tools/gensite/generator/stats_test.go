@@ -0,0 +1,48 @@
+package generator
+
+import (
+	"context"
+	"github.com/peter-mount/documentation/tools/gensite/util"
+	"github.com/peter-mount/go-kernel/v2/util/task"
+	"testing"
+)
+
+func TestStats_Timed(t *testing.T) {
+	logJson := false
+	s := &Stats{logJson: &logJson, counters: make(map[statsKey]*statsCounter)}
+	p := util.NewProducer("test", "book")
+
+	q := task.NewQueue()
+	q.AddTask(s.Timed(p, PhaseExtract, func(ctx context.Context) error {
+		// Tasks queued by a generator are timed against the same Producer
+		task.GetQueue(ctx).AddTask(s.Timed(p, PhaseWrite, func(ctx context.Context) error {
+			s.written(context.WithValue(ctx, util.ProducerKey, p), "a", true)
+			s.written(context.WithValue(ctx, util.ProducerKey, p), "b", false)
+			return nil
+		}))
+
+		// Untimed tasks are only counted
+		task.GetQueue(ctx).AddTask(func(ctx context.Context) error {
+			s.written(context.WithValue(ctx, util.ProducerKey, p), "c", true)
+			return nil
+		})
+		return nil
+	}))
+
+	if err := task.Run(q, context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	r := s.Report()
+	if len(r.Events) != 2 {
+		t.Fatalf("expected 2 events got %d", len(r.Events))
+	}
+
+	if e := r.Events[1]; e.Phase != PhaseWrite || e.Generator != "test" || e.Book != "book" || e.Written != 1 || e.Skipped != 1 {
+		t.Errorf("unexpected event %+v", e)
+	}
+
+	if len(r.Generators) != 1 || r.Generators[0].Written != 2 || r.Generators[0].Skipped != 1 {
+		t.Errorf("unexpected summary %+v", r.Generators)
+	}
+}
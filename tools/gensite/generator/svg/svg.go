@@ -3,18 +3,30 @@ package svg
 import (
 	"context"
 	"github.com/peter-mount/documentation/tools/gensite"
+	"github.com/peter-mount/documentation/tools/gensite/generator"
+	"github.com/peter-mount/documentation/tools/gensite/util"
 	"github.com/peter-mount/go-kernel/v2/util/task"
 	"github.com/peter-mount/go-kernel/v2/util/walk"
 	"gopkg.in/yaml.v2"
 	"io/ioutil"
 	"os"
+	"strings"
 )
 
 type SVG struct {
-	worker task.Queue `kernel:"worker"` // Worker queue
+	generator *generator.Generator `kernel:"inject"` // Generator
+	stats     *generator.Stats     `kernel:"inject"` // Stats
+	validate  *util.Validate       `kernel:"inject"` // Validate
+	worker    task.Queue           `kernel:"worker"` // Worker queue
 }
 
 func (s *SVG) Start() error {
+	s.generator.OnChange("config", s.changed)
+
+	if s.validate.Enabled() {
+		return nil
+	}
+
 	return walk.NewPathWalker().
 		Then(s.processFile).
 		PathHasSuffix(".yaml").
@@ -24,20 +36,54 @@ func (s *SVG) Start() error {
 
 func (s *SVG) processFile(path string, info os.FileInfo) error {
 	s.worker.AddPriorityTask(tools.PrioritySVG, func(ctx context.Context) error {
-		buf, err := ioutil.ReadFile(path)
-		if err != nil {
-			return err
-		}
+		return s.generate(ctx, path, info)
+	})
+
+	return nil
+}
+
+// changed regenerates an SVG in watch mode
+func (s *SVG) changed(ctx context.Context, path string) error {
+	if !strings.HasSuffix(path, ".yaml") {
+		return nil
+	}
 
-		file := &File{}
-		err = yaml.Unmarshal(buf, file)
-		if err != nil {
-			return err
+	info, err := os.Stat(path)
+	if err != nil {
+		// Ignore files which have been removed
+		if os.IsNotExist(err) {
+			return nil
 		}
+		return err
+	}
 
-		return file.FileHandler().
-			Write(file.FileName, info.ModTime())
-	})
+	return s.generate(ctx, path, info)
+}
 
-	return nil
+func (s *SVG) generate(ctx context.Context, path string, info os.FileInfo) error {
+	// SVG's are not part of any book
+	producer := util.NewProducer("svg", "")
+	producer.AddSource(path)
+
+	return s.stats.Timed(producer, generator.PhaseWrite, func(ctx context.Context) error {
+		return s.write(ctx, path, info)
+	}).
+		WithValue(util.ProducerKey, producer).
+		Do(ctx)
+}
+
+func (s *SVG) write(ctx context.Context, path string, info os.FileInfo) error {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	file := &File{}
+	err = yaml.Unmarshal(buf, file)
+	if err != nil {
+		return err
+	}
+
+	return file.FileHandler().
+		Write(ctx, file.FileName, info.ModTime())
 }
@@ -0,0 +1,238 @@
+package taxonomy
+
+import (
+	"context"
+	"fmt"
+	"github.com/peter-mount/documentation/tools/gensite/generator"
+	"github.com/peter-mount/documentation/tools/gensite/generator/assembly"
+	"github.com/peter-mount/documentation/tools/gensite/hugo"
+	"github.com/peter-mount/documentation/tools/gensite/util"
+	util2 "github.com/peter-mount/go-kernel/v2/util"
+	strings2 "github.com/peter-mount/go-kernel/v2/util/strings"
+	"github.com/peter-mount/go-kernel/v2/util/task"
+	"html"
+	"sort"
+	"strings"
+)
+
+const (
+	Tags         = "tags"             // Taxonomy of the tags front matter
+	Categories   = "categories"       // Taxonomy of the categories front matter
+	EntriesStage = "taxonomy.entries" // Entries have been extracted from a book
+)
+
+// Taxonomy generates an index of the pages in a book by their tags & categories, so a book gets a printed index
+// without anyone maintaining it by hand.
+//
+// Each taxonomy is written as a reference page, in the language of the book, & both are written to the book's csv
+// & Excel files. As those files are language neutral they are only written for the original book.
+type Taxonomy struct {
+	generator *generator.Generator `kernel:"inject"` // Generator
+	excel     *generator.Excel     `kernel:"inject"` // Excel
+	index     *hugo.ContentIndex   `kernel:"inject"` // ContentIndex
+	entries   util2.Map[*Entries]  // Entries extracted per book
+}
+
+// Entry is a page listed under a term of a taxonomy, one per tag or category on the page
+type Entry struct {
+	Taxonomy    string // Taxonomy, Tags or Categories
+	Term        string // The tag or category
+	Title       string // Title of the page
+	LinkTitle   string // Link title of the page
+	Description string // Description of the page
+	URL         string // URL of the page on the site
+}
+
+// Entries of a taxonomy
+type Entries []*Entry
+
+func (s *Taxonomy) Start() error {
+	s.entries = util2.NewSyncMap[*Entries]()
+
+	s.generator.
+		Register("taxonomyIndex",
+			task.Of(s.generate),
+			generator.Provides(EntriesStage),
+			generator.Translated()).
+		Register("taxonomy",
+			task.Of(s.writeTable),
+			generator.Needs(EntriesStage)).
+		OnReset(s.reset)
+	return nil
+}
+
+// reset discards the entries extracted from a book so that it can be regenerated
+func (s *Taxonomy) reset(book *hugo.Book) error {
+	s.entries.Remove(book.ID)
+	return nil
+}
+
+// generate extracts the Entries of a book & writes the reference page of each taxonomy
+func (s *Taxonomy) generate(ctx context.Context) error {
+	book := generator.GetBook(ctx)
+
+	entries, err := s.extract(ctx, book)
+	if err != nil {
+		return err
+	}
+	s.entries.Put(book.ID, &entries)
+
+	err = s.writeIndex(ctx, book, Tags, "Tags", "Pages by tag", entries.Filter(Tags))
+	if err != nil {
+		return err
+	}
+
+	return s.writeIndex(ctx, book, Categories, "Categories", "Pages by category", entries.Filter(Categories))
+}
+
+// extract the Entries of every page in a book which has tags or categories, sorted by taxonomy, term & link
+func (s *Taxonomy) extract(ctx context.Context, book *hugo.Book) (Entries, error) {
+	var entries Entries
+
+	err := s.index.Walk(ctx, book, hugo.FrontMatterActionOf(func(ctx context.Context, fm *hugo.FrontMatter) error {
+		if len(fm.Tags) == 0 && len(fm.Categories) == 0 {
+			return nil
+		}
+
+		fileName := hugo.FilePath(ctx)
+		util.GetProducer(ctx).AddSource(fileName)
+
+		entries = entries.Add(fm, book.PageURL(fileName))
+		return nil
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	entries.Sort()
+	return entries, nil
+}
+
+// Add returns the Entries with an Entry added for each tag & category of a page
+func (e Entries) Add(fm *hugo.FrontMatter, url string) Entries {
+	desc, _ := fm.Other["description"].(string)
+	add := func(taxonomy string, terms []string) {
+		for _, term := range terms {
+			e = append(e, &Entry{
+				Taxonomy:    taxonomy,
+				Term:        term,
+				Title:       strings.TrimSpace(fm.Title),
+				LinkTitle:   strings.TrimSpace(fm.LinkTitle),
+				Description: strings.TrimSpace(desc),
+				URL:         url,
+			})
+		}
+	}
+	add(Tags, fm.Tags)
+	add(Categories, fm.Categories)
+	return e
+}
+
+// Sort the Entries by taxonomy, term & link
+func (e Entries) Sort() {
+	sort.SliceStable(e, func(i, j int) bool {
+		a, b := e[i], e[j]
+		switch {
+		case a.Taxonomy != b.Taxonomy:
+			return a.Taxonomy > b.Taxonomy // Tags before Categories
+		case a.Term != b.Term:
+			return a.Term < b.Term
+		case !strings.EqualFold(a.Link(), b.Link()):
+			return strings.ToLower(a.Link()) < strings.ToLower(b.Link())
+		default:
+			return a.URL < b.URL
+		}
+	})
+}
+
+// paginate is the assembly.IndexGenerator Paginator for a taxonomy, starting a new table for each term
+func (e Entries) paginate(rowCount int, entry interface{}) bool {
+	return entry.(*Entry).Term != e[rowCount-1].Term
+}
+
+// Filter returns the Entries of a taxonomy
+func (e Entries) Filter(taxonomy string) Entries {
+	var r Entries
+	for _, entry := range e {
+		if entry.Taxonomy == taxonomy {
+			r = append(r, entry)
+		}
+	}
+	return r
+}
+
+// Link returns the text of a link to the page
+func (e *Entry) Link() string {
+	if e.LinkTitle != "" {
+		return e.LinkTitle
+	}
+	return e.Title
+}
+
+// writeIndex writes the reference page of a taxonomy, with a table per term
+func (s *Taxonomy) writeIndex(ctx context.Context, book *hugo.Book, name, title, desc string, entries Entries) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	gen := &assembly.IndexGenerator{
+		Name:      name,
+		Title:     title,
+		Desc:      desc,
+		Class:     "taxonomyIndex",
+		Paginator: entries.paginate,
+		Header: func(slice strings2.StringSlice, rowCount int) strings2.StringSlice {
+			return append(slice, fmt.Sprintf("<thead><tr><th colspan=\"2\">%s</th></tr></thead>",
+				html.EscapeString(entries[rowCount].Term)))
+		},
+		Body: func(slice strings2.StringSlice, _ int, entry interface{}) strings2.StringSlice {
+			e := entry.(*Entry)
+			return append(slice, fmt.Sprintf("<tr><td><a href=\"%s\" title=\"%s\">%s</a></td><td>%s</td></tr>",
+				html.EscapeString(e.URL),
+				html.EscapeString(e.Title),
+				html.EscapeString(e.Link()),
+				html.EscapeString(e.Description)))
+		},
+	}
+	return gen.Write(ctx, book, assembly.Iterator(entries))
+}
+
+// writeTable writes the Entries of both taxonomies to the book's csv & Excel files
+func (s *Taxonomy) writeTable(ctx context.Context) error {
+	book := generator.GetBook(ctx)
+	e := s.entries.Get(book.ID)
+	if e == nil || len(*e) == 0 {
+		return nil
+	}
+	entries := *e
+
+	return util.WithTable().
+		AsCSV(ctx, book.StaticPath("taxonomy.csv"), book.Modified()).
+		AsExcel(s.excel.Get(ctx, book.ID, book.Modified())).
+		Do(&util.Table{
+			Title: "taxonomy",
+			Columns: []string{
+				"Taxonomy",
+				"Term",
+				"Title",
+				"Link Title",
+				"Description",
+				"URL",
+			},
+			RowCount: len(entries),
+			GetRow: func(r int) interface{} {
+				return entries[r]
+			},
+			Transform: func(i interface{}) []interface{} {
+				e := i.(*Entry)
+				return []interface{}{
+					e.Taxonomy,
+					e.Term,
+					e.Title,
+					e.LinkTitle,
+					e.Description,
+					e.URL,
+				}
+			},
+		})
+}
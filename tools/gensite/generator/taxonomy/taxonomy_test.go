@@ -0,0 +1,51 @@
+package taxonomy
+
+import (
+	"github.com/peter-mount/documentation/tools/gensite/hugo"
+	"strings"
+	"testing"
+)
+
+func TestEntries_Sort(t *testing.T) {
+	var entries Entries
+	for _, p := range []struct {
+		url string
+		fm  *hugo.FrontMatter
+	}{
+		{"/os/", &hugo.FrontMatter{Title: "zebra", Tags: []string{"os", "io"}, Categories: []string{"reference"}}},
+		{"/io/", &hugo.FrontMatter{Title: "Input", LinkTitle: "apple", Tags: []string{"io"}}},
+		{"/vdu/", &hugo.FrontMatter{Title: "VDU", Tags: []string{"os"}, Categories: []string{"guide"}}},
+		{"/none/", &hugo.FrontMatter{Title: "None"}},
+	} {
+		entries = entries.Add(p.fm, p.url)
+	}
+	entries.Sort()
+
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Taxonomy+" "+e.Term+" "+e.Link()+" "+e.URL)
+	}
+	want := []string{
+		"tags io apple /io/",
+		"tags io zebra /os/",
+		"tags os VDU /vdu/",
+		"tags os zebra /os/",
+		"categories guide VDU /vdu/",
+		"categories reference zebra /os/",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// A table per term
+	tags := entries.Filter(Tags)
+	var pages []int
+	for i, e := range tags {
+		if i > 0 && tags.paginate(i, e) {
+			pages = append(pages, i)
+		}
+	}
+	if len(pages) != 1 || pages[0] != 2 {
+		t.Errorf("got new tables at %v want [2]", pages)
+	}
+}
@@ -0,0 +1,69 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"github.com/peter-mount/documentation/tools/gensite/hugo"
+	"github.com/peter-mount/documentation/tools/gensite/util/schema"
+	"github.com/peter-mount/go-kernel/v2/log"
+	"os"
+)
+
+// validateBooks validates the front matter of every book against the schemas of the generators it uses.
+// Errors are shown as file:line on stderr & the run fails if there are any.
+func (g *Generator) validateBooks(_ context.Context) error {
+	count := 0
+	// Translations share untranslated pages so only report an error once
+	seen := make(map[string]bool)
+	for _, book := range g.bookShelf.Books() {
+		errs, err := g.validateBook(book)
+		if err != nil {
+			return fmt.Errorf("book %s: %w", book.ID, err)
+		}
+
+		for _, e := range errs {
+			if s := e.Error(); !seen[s] {
+				seen[s] = true
+				_, _ = fmt.Fprintln(os.Stderr, s)
+				count++
+			}
+		}
+	}
+
+	_, _ = fmt.Fprintf(os.Stderr, "%d front matter errors\n", count)
+	if count > 0 {
+		return fmt.Errorf("front matter is invalid")
+	}
+	return nil
+}
+
+// validateBook validates a book's pages. Values declared unique must be unique across the whole book.
+func (g *Generator) validateBook(book *hugo.Book) ([]*schema.Error, error) {
+	v := schema.NewValidator()
+
+	nodes, _ := g.generators.scheduleBook(book)
+	keys := 0
+	for _, n := range nodes {
+		for k, s := range n.schemas {
+			v.Add(k, s)
+			keys++
+		}
+	}
+	if keys == 0 {
+		return nil, nil
+	}
+
+	log.Printf("Validating %s", book.ID)
+
+	files, err := g.index.Files(book)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, fileName := range files {
+		if err := v.ValidateFile(fileName); err != nil {
+			return nil, err
+		}
+	}
+	return v.Errors(), nil
+}
@@ -0,0 +1,65 @@
+package volume
+
+import (
+	"context"
+	"github.com/peter-mount/documentation/tools/gensite/generator"
+	"github.com/peter-mount/documentation/tools/gensite/util"
+	"github.com/peter-mount/go-kernel/v2/util/task"
+	"strings"
+)
+
+// Volume generates the index of the volumes making up a book, see hugo.BookShelf
+type Volume struct {
+	generator *generator.Generator `kernel:"inject"` // Generator
+}
+
+// Output is used for generating the index page front matter
+type Output struct {
+	Nometa  bool     `yaml:"nometa"`
+	Volumes []*Entry `yaml:"volumes"`
+}
+
+// Entry describes a volume in the index
+type Entry struct {
+	ID       string `yaml:"id"`                 // ID of the volume
+	Title    string `yaml:"title"`              // Title of the volume
+	SubTitle string `yaml:"subTitle,omitempty"` // SubTitle of the volume
+	Path     string `yaml:"path"`               // Path of the volume on the site
+}
+
+func (s *Volume) Start() error {
+	s.generator.Register("volumeIndex", task.Of(s.writeIndex))
+	return nil
+}
+
+func (s *Volume) writeIndex(ctx context.Context) error {
+	book := generator.GetBook(ctx)
+
+	volumes := book.VolumeBooks()
+	if len(volumes) == 0 {
+		return nil
+	}
+
+	r := Output{Nometa: true}
+	for _, v := range volumes {
+		util.GetProducer(ctx).AddSource(v.ContentPath("_index.html"))
+		r.Volumes = append(r.Volumes, &Entry{
+			ID:       v.ID,
+			Title:    v.Title,
+			SubTitle: v.SubTitle,
+			Path:     "/" + strings.ToLower(v.WebPath()) + "/",
+		})
+	}
+
+	return util.ReferenceFileBuilder(
+		"Volumes",
+		"Volumes in this book",
+		"manual",
+		5,
+		book.Modified(),
+	).
+		Yaml(r).
+		WrapAsFrontMatter().
+		FileHandler().
+		Write(ctx, util.ReferenceFilename(book.ContentPath(), "volumes", "_index.html"), book.Modified())
+}
@@ -0,0 +1,233 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"github.com/fsnotify/fsnotify"
+	"github.com/peter-mount/documentation/tools/gensite"
+	"github.com/peter-mount/documentation/tools/gensite/hugo"
+	"github.com/peter-mount/go-kernel/v2/log"
+	"github.com/peter-mount/go-kernel/v2/util/task"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	watchDelay = 500 * time.Millisecond // Time to wait for further changes before regenerating, e.g. an editor saving many files
+)
+
+// startWatcher starts watching content & any directory with a ChangeHandler, regenerating books as they change.
+// Hugo, when running as a server, will then pick up the regenerated pages.
+func (g *Generator) startWatcher(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	dirs := []string{"content"}
+	for dir := range g.changes {
+		dirs = append(dirs, dir)
+	}
+
+	for _, dir := range dirs {
+		if err := addWatch(watcher, dir); err != nil {
+			_ = watcher.Close()
+			return err
+		}
+	}
+
+	log.Printf("Watching %s for changes", strings.Join(dirs, ", "))
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- g.processEvents(watcher)
+	}()
+
+	// Keep running once everything else has completed, e.g. when hugo is not running as a server
+	task.GetQueue(ctx).AddPriorityTask(math.MaxInt, func(_ context.Context) error {
+		return <-errs
+	})
+
+	return nil
+}
+
+// addWatch adds a directory & it's subdirectories to the watcher.
+// Generated reference pages are ignored as we write those.
+func addWatch(watcher *fsnotify.Watcher, dir string) error {
+	err := filepath.Walk(dir, func(fileName string, info os.FileInfo, err error) error {
+		switch {
+		case err != nil:
+			return err
+		case !info.IsDir():
+			return nil
+		case info.Name() == "reference":
+			return filepath.SkipDir
+		default:
+			return watcher.Add(fileName)
+		}
+	})
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// ignoreChange returns true if a change to a file is of no interest, e.g. generated content or editor temp files
+func ignoreChange(fileName string) bool {
+	base := filepath.Base(fileName)
+	return strings.Contains(fileName+"/", "/reference/") ||
+		strings.HasPrefix(base, ".") ||
+		strings.HasSuffix(base, "~")
+}
+
+// processEvents processes events from the watcher until it fails.
+// Changes are collected until none have been seen for watchDelay so that they are processed together.
+func (g *Generator) processEvents(watcher *fsnotify.Watcher) error {
+	defer watcher.Close()
+
+	changed := make(map[string]bool)
+	var timer <-chan time.Time
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+
+			if event.Op == fsnotify.Chmod || ignoreChange(event.Name) {
+				continue
+			}
+
+			// Watch any new directories
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := addWatch(watcher, event.Name); err != nil {
+						return err
+					}
+				}
+			}
+
+			changed[event.Name] = true
+			timer = time.After(watchDelay)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return err
+
+		case <-timer:
+			var files []string
+			for fileName := range changed {
+				files = append(files, fileName)
+			}
+			sort.Strings(files)
+
+			changed = make(map[string]bool)
+			timer = nil
+
+			g.changed(files)
+		}
+	}
+}
+
+// changed regenerates the books containing the changed files & passes any other files to their ChangeHandler.
+// Errors are reported but do not stop watching, as the next change may well fix the problem.
+func (g *Generator) changed(files []string) {
+	books := make(map[*hugo.Book]bool)
+
+	for _, fileName := range files {
+		if book := g.bookFor(fileName); book != nil {
+			books[book] = true
+			continue
+		}
+
+		for dir, h := range g.changes {
+			if strings.HasPrefix(fileName, dir+"/") {
+				log.Printf("Changed %s", fileName)
+				if err := runTasks(func(ctx context.Context) error {
+					return h(ctx, fileName)
+				}); err != nil {
+					_, _ = fmt.Fprintf(os.Stderr, "%s: %v\n", fileName, err)
+				}
+			}
+		}
+	}
+
+	// Books made up of volumes which have changed must also be regenerated, as must translations as they share
+	// any untranslated pages
+	for _, book := range g.bookShelf.Books() {
+		for v := range books {
+			if book.Includes(v) || book.Original() == v {
+				books[book] = true
+				break
+			}
+		}
+	}
+
+	// Regenerate in the same order as the initial run
+	for _, book := range g.bookShelf.Books() {
+		if books[book] {
+			if err := g.regenerate(book); err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "book %s: %v\n", book.ID, err)
+			}
+		}
+	}
+}
+
+// bookFor returns the book containing a file, nil if none.
+// If books are nested then the innermost book is returned.
+func (g *Generator) bookFor(fileName string) *hugo.Book {
+	var book *hugo.Book
+	for _, b := range g.bookShelf.Books() {
+		if strings.HasPrefix(fileName, b.ContentPath()+"/") &&
+			(book == nil || len(b.ContentPath()) > len(book.ContentPath())) {
+			book = b
+		}
+	}
+	return book
+}
+
+// regenerate discards any state held for a book then runs it's generators again
+func (g *Generator) regenerate(book *hugo.Book) error {
+	log.Printf("Regenerating %s", book.ID)
+
+	book.Refresh()
+
+	for _, h := range g.resets {
+		if err := h(book); err != nil {
+			return err
+		}
+	}
+
+	// Forget what was generated so anything no longer generated can be pruned
+	g.manifest.RemoveBook(book.ID)
+
+	return runTasks(
+		func(ctx context.Context) error {
+			return g.invokeBook(ctx, book)
+		},
+		func(ctx context.Context) error {
+			// Prune, then save the cache & manifest once the tasks queued by the generators have completed
+			task.GetQueue(ctx).
+				AddPriorityTask(tools.PriorityPrune, g.prune.prune).
+				AddPriorityTask(tools.PriorityCache, g.cache.save).
+				AddPriorityTask(tools.PriorityManifest, g.manifest.save)
+			return nil
+		})
+}
+
+// runTasks runs tasks on a new queue, along with any tasks they add to it.
+// This allows changes to be processed whilst the main worker queue is blocked, e.g. by hugo running as a server.
+func runTasks(tasks ...task.Task) error {
+	q := task.NewQueue()
+	for _, t := range tasks {
+		q.AddTask(t)
+	}
+	return task.Run(q, context.Background())
+}
@@ -0,0 +1,18 @@
+package z80
+
+import (
+	"context"
+	"github.com/peter-mount/documentation/tools/gensite/generator"
+	"github.com/peter-mount/documentation/tools/gensite/generator/assembly"
+	util2 "github.com/peter-mount/go-kernel/v2/util"
+)
+
+func (s *Z80) writeDataset(ctx context.Context) error {
+	book := generator.GetBook(ctx)
+
+	var ops []*assembly.Opcode
+	for _, i := range s.Opcodes(book) {
+		ops = append(ops, i.Opcode)
+	}
+	return assembly.WriteDataset(ctx, book, util2.NewIterator(ops...))
+}
@@ -0,0 +1,43 @@
+package z80
+
+import (
+	"context"
+	"github.com/peter-mount/documentation/tools/gensite/generator"
+	"github.com/peter-mount/documentation/tools/gensite/generator/assembly"
+	"github.com/peter-mount/documentation/tools/gensite/hugo"
+	"github.com/peter-mount/go-kernel/v2/log"
+)
+
+func (s *Z80) extractOpcodes(ctx context.Context) error {
+	book := generator.GetBook(ctx)
+	instructions := s.Instructions(book)
+
+	// Only run once per Book ID
+	if !s.extracted.Add(book.ID) {
+		return nil
+	}
+
+	log.Println("Scanning Z80 opcodes")
+	err := s.index.Walk(ctx, book, hugo.FrontMatterActionOf().
+		Then(instructions.ExtractFrontMatter).
+		WithNotes(instructions.Notes()).
+		Context(assembly.InstructionsKey, instructions))
+	if err != nil {
+		return err
+	}
+
+	instructions.Normalise()
+
+	return nil
+}
+
+// Opcodes returns the Instructions extracted from a book, ordered by prefix then opcode
+func (s *Z80) Opcodes(book *hugo.Book) []*Instruction {
+	var r []*Instruction
+	inst := s.Instructions(book).Iterator()
+	for inst.HasNext() {
+		r = append(r, NewInstruction(inst.Next()))
+	}
+	SortByOpcode(r)
+	return r
+}
@@ -0,0 +1,141 @@
+package z80
+
+import (
+	"context"
+	"github.com/peter-mount/documentation/tools/gensite/generator"
+	"github.com/peter-mount/documentation/tools/gensite/hugo"
+	"github.com/peter-mount/documentation/tools/gensite/util"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// HexGrid is the opcode matrix of each prefix
+type HexGrid map[string]*HexMap
+
+// HexMap is the opcode matrix of a prefix
+type HexMap [16][16]*HexCell
+
+// HexCell is a cell in the matrix, in the format used by the theme's 6502/hexMap partial
+type HexCell struct {
+	Label   string `yaml:"label"`             // Label to show, e.g. "NOP", blank if unused
+	Op      string `yaml:"op,omitempty"`      // Opcode
+	Link    string `yaml:"link,omitempty"`    // Link to the matrix of a prefix
+	Size    int    `yaml:"size,omitempty"`    // Size in bytes
+	Cycles  string `yaml:"cycles,omitempty"`  // T-states of each machine cycle
+	MCycles int    `yaml:"mcycles,omitempty"` // Machine cycles
+	Colour  string `yaml:"colour,omitempty"`  // Colour
+}
+
+// Output is used for generating the front matter of an opcode matrix page
+type Output struct {
+	HexGrid map[string]*HexMap `yaml:"hexGrid"`
+}
+
+// NewHexGrid returns the HexGrid of some Instructions.
+// Documented opcodes take precedence over undocumented ones with the same code.
+func NewHexGrid(inst []*Instruction) HexGrid {
+	hg := HexGrid{}
+	for _, i := range inst {
+		idx, err := strconv.ParseUint(i.Index, 16, 8)
+		if err != nil {
+			continue
+		}
+
+		c := hg.get(i.Prefix)[idx>>4][idx&0xf]
+		if c.Label != "" && (i.Undocumented || c.Colour != Undocumented) {
+			continue
+		}
+
+		*c = HexCell{
+			Label:   i.Op,
+			Op:      i.Code,
+			Size:    i.Bytes.Int(),
+			Cycles:  i.Cycles.String(),
+			MCycles: i.MCycles,
+			Colour:  i.Colour,
+		}
+	}
+
+	// Link the prefixes to their own matrix. In reverse so DDCB adds the DD matrix if it has no other opcodes
+	for p := len(Prefixes) - 1; p > 0; p-- {
+		prefix := Prefixes[p]
+		if _, exists := hg[prefix]; !exists {
+			continue
+		}
+		parent := prefix[:len(prefix)-2]
+		idx, _ := strconv.ParseUint(prefix[len(prefix)-2:], 16, 8)
+		*hg.get(parent)[idx>>4][idx&0xf] = HexCell{
+			Label:  "Instruction Prefix",
+			Op:     prefix,
+			Link:   linkTo(parent, prefix),
+			Colour: "brown",
+		}
+	}
+
+	return hg
+}
+
+// get returns the HexMap of a prefix, creating it if required
+func (hg HexGrid) get(prefix string) *HexMap {
+	m, exists := hg[prefix]
+	if !exists {
+		m = &HexMap{}
+		for r := range m {
+			for c := range m[r] {
+				m[r][c] = &HexCell{}
+			}
+		}
+		hg[prefix] = m
+	}
+	return m
+}
+
+// pagePath returns the path of the page of a prefix within the reference section
+func pagePath(prefix string) string {
+	return path.Join("hexgrid", strings.ToLower(prefix))
+}
+
+// linkTo returns the relative link from the page of one prefix to another.
+// The pages of the prefixes are within that of the unprefixed opcodes.
+func linkTo(from, to string) string {
+	if from == "" {
+		return strings.ToLower(to) + "/"
+	}
+	return "../" + strings.ToLower(to) + "/"
+}
+
+func (s *Z80) writeOpsHexGrid(ctx context.Context) error {
+	book := generator.GetBook(ctx)
+	hg := NewHexGrid(s.Opcodes(book))
+
+	for i, prefix := range Prefixes {
+		if m, exists := hg[prefix]; exists {
+			if err := hg.writePage(ctx, book, i, prefix, m); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (hg HexGrid) writePage(ctx context.Context, book *hugo.Book, weight int, prefix string, m *HexMap) error {
+	title := book.I18n("reference_hexgrid_title", "Opcode Matrix")
+	desc := book.I18n("reference_hexgrid_desc", "Instructions shown in an Opcode Matrix")
+	if prefix != "" {
+		title = title + " " + prefix
+		desc = book.I18n("reference_hexgrid_prefix_desc", "Instructions with the prefix") + " 0x" + prefix
+	}
+
+	return util.ReferenceFileBuilder(
+		title,
+		desc,
+		"manual",
+		10+weight,
+		book.Modified(),
+	).
+		Yaml(Output{HexGrid: map[string]*HexMap{prefix: m}}).
+		WrapAsFrontMatter().
+		FileHandler().
+		Write(ctx, util.ReferenceFilename(book.ContentPath(), pagePath(prefix), book.PageName("_index.html")), book.Modified())
+}
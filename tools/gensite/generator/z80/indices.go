@@ -0,0 +1,72 @@
+package z80
+
+import (
+	"context"
+	"fmt"
+	"github.com/peter-mount/documentation/tools/gensite/generator"
+	"github.com/peter-mount/documentation/tools/gensite/generator/assembly"
+	strings2 "github.com/peter-mount/go-kernel/v2/util/strings"
+)
+
+func (s *Z80) writeOpsIndex(ctx context.Context) error {
+	book := generator.GetBook(ctx)
+	inst := s.Opcodes(book)
+
+	gen := &assembly.IndexGenerator{
+		Name:  "opcodes",
+		Title: "Instruction List by opcode",
+		Desc:  "",
+		Class: "opIndex2",
+		// A table per prefix
+		Paginator: func(rowCount int, entry interface{}) bool {
+			return entry.(*Instruction).Prefix != inst[rowCount-1].Prefix
+		},
+		Header: func(slice strings2.StringSlice, rowCount int) strings2.StringSlice {
+			if len(inst) > 0 && inst[rowCount].Prefix != "" {
+				slice = append(slice, fmt.Sprintf("<caption>Opcodes with prefix 0x%s</caption>", inst[rowCount].Prefix))
+			}
+			return z80IndexHeader(slice, rowCount)
+		},
+		Body: z80IndexBody,
+	}
+	return gen.Write(ctx, book, assembly.Iterator(inst))
+}
+
+func (s *Z80) writeOpsHexIndex(ctx context.Context) error {
+	book := generator.GetBook(ctx)
+	inst := s.Opcodes(book)
+	SortByName(inst)
+
+	gen := &assembly.IndexGenerator{
+		Name:   "instructions",
+		Title:  "Instruction List by name",
+		Desc:   "",
+		Class:  "opIndex2",
+		Header: z80IndexHeader,
+		Body:   z80IndexBody,
+	}
+	return gen.Write(ctx, book, assembly.Iterator(inst))
+}
+
+// z80IndexHeader shared
+func z80IndexHeader(slice strings2.StringSlice, _ int) strings2.StringSlice {
+	return append(slice, "<thead><tr>",
+		"<th>Instruction</th>",
+		"<th>Opcode</th>",
+		"<th>Bytes</th>",
+		"<th>T-states</th>",
+		"<th>M-cycles</th>",
+		"</tr></thead>")
+}
+
+// z80IndexBody shared
+func z80IndexBody(slice strings2.StringSlice, _ int, entry interface{}) strings2.StringSlice {
+	op := entry.(*Instruction)
+	class := ""
+	if op.Undocumented {
+		class = " class=\"" + Undocumented + "\""
+	}
+	t, m := op.Timing()
+	return append(slice, fmt.Sprintf("<tr%s><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>",
+		class, Z80OpcodeFormatter(op.Opcode), op.Code, op.Bytes.String(), t, m))
+}
@@ -0,0 +1,115 @@
+package z80
+
+import (
+	"github.com/peter-mount/documentation/tools/gensite/generator/assembly"
+	"html"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Prefixes are the opcode prefixes in the order their tables are shown, "" being the unprefixed opcodes
+var Prefixes = []string{"", "CB", "DD", "ED", "FD", "DDCB", "FDCB"}
+
+// Undocumented is the colour of undocumented opcodes
+const Undocumented = "undocumented"
+
+// Instruction is a Z80 Opcode split into its prefix & the opcode within that prefix's table
+type Instruction struct {
+	*assembly.Opcode
+	Prefix       string // Prefix of the opcode, one of Prefixes
+	Index        string // Hex of the opcode within the prefix's table, e.g. "06" for "DDCBnn06"
+	TStates      int    // Clock cycles, the total of Cycles
+	MCycles      int    // Machine cycles, one per entry in Cycles
+	Undocumented bool   // true if the opcode is undocumented
+}
+
+func NewInstruction(op *assembly.Opcode) *Instruction {
+	i := &Instruction{
+		Opcode:       op,
+		Undocumented: op.Colour == Undocumented,
+	}
+
+	// Operands are "nn" so are not part of the index
+	code := strings.ReplaceAll(op.Code, "nn", "")
+	if len(code) >= 2 {
+		i.Prefix = code[:len(code)-2]
+		i.Index = code[len(code)-2:]
+	}
+
+	// Cycles are the T-states of each machine cycle, e.g. "4,4,3,5,3". "?" or "*" mark an unknown or variable count
+	for _, c := range strings.Split(op.Cycles.String(), ",") {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+		i.MCycles++
+		if t, err := strconv.Atoi(c); err == nil {
+			i.TStates += t
+		}
+	}
+
+	return i
+}
+
+// Timing returns the T-states & M-cycles for display, blank if the opcode has no cycles
+func (i *Instruction) Timing() (string, string) {
+	if i.MCycles == 0 {
+		return "", ""
+	}
+	return strconv.Itoa(i.TStates), strconv.Itoa(i.MCycles)
+}
+
+// prefixOrder returns the position of a prefix in Prefixes, unknown prefixes being last
+func prefixOrder(prefix string) int {
+	for i, p := range Prefixes {
+		if p == prefix {
+			return i
+		}
+	}
+	return len(Prefixes)
+}
+
+// SortByOpcode sorts Instructions by prefix then opcode
+func SortByOpcode(a []*Instruction) {
+	sort.SliceStable(a, func(i, j int) bool {
+		pi, pj := prefixOrder(a[i].Prefix), prefixOrder(a[j].Prefix)
+		if pi != pj {
+			return pi < pj
+		}
+		return a[i].Index < a[j].Index
+	})
+}
+
+// SortByName sorts Instructions by name then opcode
+func SortByName(a []*Instruction) {
+	SortByOpcode(a)
+	sort.SliceStable(a, func(i, j int) bool {
+		return a[i].Op < a[j].Op
+	})
+}
+
+// OpcodeBytes returns the bytes of an opcode excluding operands, i.e. any prefix & the opcode, so "CB06" is 2.
+func OpcodeBytes(code string) int {
+	return len(strings.ReplaceAll(code, "nn", "")) / 2
+}
+
+// operandRegex matches the values following an opcode within an instruction, e.g. n in "LD A, n" or d in "(IX+d)"
+var operandRegex = regexp.MustCompile(`\b(nn|dd|n|d|e)\b`)
+
+// Z80OpcodeFormatter formats an instruction with its operands separated by ", " & the values following the opcode
+// in italics, e.g. "LD A,(IX+d)" is "LD A, (IX+<em>d</em>)"
+func Z80OpcodeFormatter(op *assembly.Opcode) string {
+	mnemonic, operands, _ := strings.Cut(strings.TrimSpace(op.Op), " ")
+
+	s := html.EscapeString(mnemonic)
+	if operands = strings.TrimSpace(operands); operands != "" {
+		var a []string
+		for _, o := range strings.Split(operands, ",") {
+			a = append(a, operandRegex.ReplaceAllString(html.EscapeString(strings.TrimSpace(o)), "<em>$1</em>"))
+		}
+		s = s + " " + strings.Join(a, ", ")
+	}
+	return s
+}
@@ -0,0 +1,108 @@
+package z80
+
+import (
+	"fmt"
+	"github.com/peter-mount/documentation/tools/gensite/generator/assembly"
+	"testing"
+)
+
+func TestNewInstruction(t *testing.T) {
+	for code, want := range map[string]string{
+		"00":       " 00",
+		"3Enn":     " 3E",
+		"CB06":     "CB 06",
+		"DD7Enn":   "DD 7E",
+		"ED47":     "ED 47",
+		"DDCBnn06": "DDCB 06",
+	} {
+		i := NewInstruction(&assembly.Opcode{Code: code})
+		if got := i.Prefix + " " + i.Index; got != want {
+			t.Errorf("%s got %q want %q", code, got, want)
+		}
+	}
+
+	for cycles, want := range map[string]string{
+		"":            "0 0",
+		"4":           "4 1",
+		"4,4,3,5,3":   "19 5",
+		"4, 3, ?":     "7 3",
+		"4,4,3,5,4,3": "23 6",
+	} {
+		i := NewInstruction(&assembly.Opcode{Code: "00", Cycles: &assembly.OpcodeType{Value: cycles}})
+		if got := fmt.Sprintf("%d %d", i.TStates, i.MCycles); got != want {
+			t.Errorf("%q got %q want %q", cycles, got, want)
+		}
+	}
+}
+
+func TestOpcodeBytes(t *testing.T) {
+	for code, want := range map[string]int{"00": 1, "3Enn": 1, "CB06": 2, "DD21nnnn": 2, "DDCBnn06": 3} {
+		if got := OpcodeBytes(code); got != want {
+			t.Errorf("%s got %d want %d", code, got, want)
+		}
+	}
+}
+
+func TestZ80OpcodeFormatter(t *testing.T) {
+	for op, want := range map[string]string{
+		"NOP":          "NOP",
+		"LD A,(IX+d)":  "LD A, (IX+<em>d</em>)",
+		"JP NZ,nn":     "JP NZ, <em>nn</em>",
+		"IN A,(n)":     "IN A, (<em>n</em>)",
+		"DJNZ e":       "DJNZ <em>e</em>",
+		"ADD A,IXh":    "ADD A, IXh",
+		"EX AF, AF'":   "EX AF, AF&#39;",
+		"LD (DE), A":   "LD (DE), A",
+		"SLL (IX+dd)":  "SLL (IX+<em>dd</em>)",
+		"RES 0,(IY+d)": "RES 0, (IY+<em>d</em>)",
+	} {
+		if got := Z80OpcodeFormatter(&assembly.Opcode{Op: op}); got != want {
+			t.Errorf("%q got %q want %q", op, got, want)
+		}
+	}
+}
+
+func TestNewHexGrid(t *testing.T) {
+	op := func(code, name, colour string) *Instruction {
+		return NewInstruction(&assembly.Opcode{Code: code, Op: name, Colour: colour})
+	}
+
+	hg := NewHexGrid([]*Instruction{
+		op("00", "NOP", "grey"),
+		op("ED44", "NEG", "green"),
+		op("ED4C", "NEG", Undocumented),
+		op("ED4C", "MLT BC", "green"), // Documented replaces undocumented
+		op("ED44", "NEG", Undocumented),
+		op("DDCBnn06", "RLC (IX+d)", "yellow"),
+	})
+
+	for _, test := range []struct {
+		prefix string
+		idx    int
+		label  string
+		link   string
+	}{
+		{"", 0x00, "NOP", ""},
+		{"", 0xDD, "Instruction Prefix", "dd/"},
+		{"", 0xED, "Instruction Prefix", "ed/"},
+		{"", 0xCB, "", ""},
+		{"ED", 0x44, "NEG", ""},
+		{"ED", 0x4C, "MLT BC", ""},
+		{"DD", 0xCB, "Instruction Prefix", "../ddcb/"},
+		{"DDCB", 0x06, "RLC (IX+d)", ""},
+	} {
+		m, exists := hg[test.prefix]
+		if !exists {
+			t.Errorf("no matrix for %q", test.prefix)
+			continue
+		}
+		c := m[test.idx>>4][test.idx&0xf]
+		if c.Label != test.label || c.Link != test.link {
+			t.Errorf("%s%02X got %q %q", test.prefix, test.idx, c.Label, c.Link)
+		}
+	}
+
+	if hg["ED"][4][4].Colour != "green" {
+		t.Errorf("undocumented replaced documented")
+	}
+}
@@ -0,0 +1,72 @@
+package z80
+
+import (
+	"github.com/peter-mount/documentation/tools/gensite/generator"
+	"github.com/peter-mount/documentation/tools/gensite/generator/assembly"
+	"github.com/peter-mount/documentation/tools/gensite/hugo"
+	"github.com/peter-mount/documentation/tools/gensite/util/schema"
+	util2 "github.com/peter-mount/go-kernel/v2/util"
+	"github.com/peter-mount/go-kernel/v2/util/task"
+)
+
+const (
+	OpcodesStage = "z80.opcodes" // Opcodes have been extracted from a book
+)
+
+// Z80 generates the instruction reference pages of the Z80 book.
+//
+// Unlike the 6502, Z80 opcodes can have a prefix, CB, DD, ED, FD, DDCB or FDCB, each with its own table of opcodes.
+// So the indices are grouped by prefix & each prefix has its own page in the opcode matrix.
+type Z80 struct {
+	generator    *generator.Generator              `kernel:"inject"` // Generator
+	index        *hugo.ContentIndex                `kernel:"inject"` // ContentIndex
+	extracted    util2.Set[string]                 // Set of book ID's so that we run once per book
+	instructions util2.Map[*assembly.Instructions] // Map of extracted data
+}
+
+func (s *Z80) Start() error {
+	s.extracted = util2.NewSyncSet[string]()
+	s.instructions = util2.NewSyncMap[*assembly.Instructions]()
+
+	s.generator.
+		Register("z80Opcodes",
+			task.Of(s.extractOpcodes),
+			generator.Provides(OpcodesStage),
+			generator.Translated(),
+			generator.Validates("op", schema.String()),
+			generator.Validates("codes", assembly.Schema())).
+		Register("z80OpsIndex",
+			task.Of(s.writeOpsIndex),
+			generator.Needs(OpcodesStage),
+			generator.Translated()).
+		Register("z80OpsHexIndex",
+			task.Of(s.writeOpsHexIndex),
+			generator.Needs(OpcodesStage),
+			generator.Translated()).
+		Register("z80OpsHexGrid",
+			task.Of(s.writeOpsHexGrid),
+			generator.Needs(OpcodesStage),
+			generator.Translated()).
+		Register("z80Dataset",
+			task.Of(s.writeDataset),
+			generator.Needs(OpcodesStage)).
+		OnReset(s.reset)
+
+	return nil
+}
+
+// reset discards the opcodes extracted from a book so that it can be regenerated
+func (s *Z80) reset(book *hugo.Book) error {
+	s.extracted.Remove(book.ID)
+	s.instructions.Remove(book.ID)
+	return nil
+}
+
+func (s *Z80) Instructions(b *hugo.Book) *assembly.Instructions {
+	return s.instructions.ComputeIfAbsent(b.ID, func(s string) *assembly.Instructions {
+		inst := assembly.ComputeNewInstructions(s)
+		inst.OpcodeFormatter = Z80OpcodeFormatter
+		inst.OpcodeBytes = OpcodeBytes
+		return inst
+	})
+}
@@ -0,0 +1,165 @@
+package hugo
+
+import (
+	"bytes"
+	"golang.org/x/net/html"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Body is the content of a page following its front matter.
+// The html tree & shortcodes are only parsed when first requested.
+type Body struct {
+	raw        []byte       // The raw content
+	line       int          // Line in the page the content starts on
+	htmlOnce   sync.Once    // Parse html once
+	node       *html.Node   // Parsed html
+	htmlErr    error        // Error from parsing html
+	scOnce     sync.Once    // Parse shortcodes once
+	shortcodes []*Shortcode // Parsed shortcodes
+}
+
+// Shortcode is a hugo shortcode used within a page, e.g. {{< book/link href="/bbc/" >}}
+type Shortcode struct {
+	Name     string            // Name of the shortcode
+	Args     []string          // Positional arguments
+	Params   map[string]string // Named arguments
+	Markdown bool              // true if {{% %}} rather than {{< >}}
+	Line     int               // Line in the page the shortcode starts on
+}
+
+var shortcodeRegex = regexp.MustCompile(`(?s){{([<%])(.*?)([>%])}}`)
+
+// newBody returns the Body of a page starting at offset within buf
+func newBody(buf []byte, offset int) *Body {
+	return &Body{
+		raw:  buf[offset:],
+		line: bytes.Count(buf[:offset], []byte("\n")) + 1,
+	}
+}
+
+// Raw returns the content as is
+func (b *Body) Raw() []byte {
+	return b.raw
+}
+
+// Line returns the line in the page the content starts on
+func (b *Body) Line() int {
+	return b.line
+}
+
+// HTML returns the content parsed as html.
+// As the content is a fragment, it will be within the body element of the returned document.
+func (b *Body) HTML() (*html.Node, error) {
+	b.htmlOnce.Do(func() {
+		b.node, b.htmlErr = html.Parse(bytes.NewReader(b.raw))
+	})
+	return b.node, b.htmlErr
+}
+
+// Shortcodes returns the shortcodes used in the content in the order they appear.
+// Closing shortcodes and commented out ones, e.g. {{</* name */>}}, are not included.
+func (b *Body) Shortcodes() []*Shortcode {
+	b.scOnce.Do(func() {
+		for _, m := range shortcodeRegex.FindAllSubmatchIndex(b.raw, -1) {
+			open, content, closing := string(b.raw[m[2]:m[3]]), string(b.raw[m[4]:m[5]]), string(b.raw[m[6]:m[7]])
+			if (open == "<") != (closing == ">") {
+				continue
+			}
+
+			content = strings.TrimSpace(content)
+			if content == "" || strings.HasPrefix(content, "/") {
+				continue
+			}
+
+			if sc := parseShortcode(content); sc != nil {
+				sc.Markdown = open == "%"
+				sc.Line = b.line + bytes.Count(b.raw[:m[0]], []byte("\n"))
+				b.shortcodes = append(b.shortcodes, sc)
+			}
+		}
+	})
+	return b.shortcodes
+}
+
+// parseShortcode parses the content of a shortcode, its name followed by either positional or named arguments
+func parseShortcode(s string) *Shortcode {
+	tokens := shortcodeTokens(s)
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	sc := &Shortcode{Name: tokens[0], Params: map[string]string{}}
+	for _, t := range tokens[1:] {
+		if k, v, ok := cutParam(t); ok {
+			sc.Params[k] = unquote(v)
+		} else {
+			sc.Args = append(sc.Args, unquote(t))
+		}
+	}
+	return sc
+}
+
+// cutParam splits a named argument, name=value, into its name & value. The name may be quoted, e.g. "(an)"=1
+func cutParam(t string) (string, string, bool) {
+	if t != "" && (t[0] == '"' || t[0] == '`') {
+		if i := strings.IndexByte(t[1:], t[0]) + 1; i > 0 && strings.HasPrefix(t[i+1:], "=") {
+			return unquote(t[:i+1]), t[i+2:], true
+		}
+		return "", "", false
+	}
+
+	k, v, ok := strings.Cut(t, "=")
+	return k, v, ok && k != ""
+}
+
+// shortcodeTokens splits s on whitespace, keeping quoted strings together
+func shortcodeTokens(s string) []string {
+	var tokens []string
+	var sb strings.Builder
+	var quote rune
+	escape := false
+	for _, c := range s {
+		switch {
+		case escape:
+			escape = false
+			sb.WriteRune(c)
+		case quote == '"' && c == '\\':
+			escape = true
+			sb.WriteRune(c)
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+			sb.WriteRune(c)
+		case c == '"' || c == '`':
+			quote = c
+			sb.WriteRune(c)
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			if sb.Len() > 0 {
+				tokens = append(tokens, sb.String())
+				sb.Reset()
+			}
+		default:
+			sb.WriteRune(c)
+		}
+	}
+	if sb.Len() > 0 {
+		tokens = append(tokens, sb.String())
+	}
+	return tokens
+}
+
+// unquote removes the quotes from a shortcode argument
+func unquote(s string) string {
+	if len(s) >= 2 {
+		switch {
+		case s[0] == '`' && s[len(s)-1] == '`':
+			return s[1 : len(s)-1]
+		case s[0] == '"' && s[len(s)-1] == '"':
+			return strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(s[1 : len(s)-1])
+		}
+	}
+	return s
+}
@@ -0,0 +1,86 @@
+package hugo
+
+import (
+	"fmt"
+	"golang.org/x/net/html"
+	"strings"
+	"testing"
+)
+
+func TestFrontMatter_parse_body(t *testing.T) {
+	for name, page := range map[string]string{
+		"yaml": "---\ntitle: \"Test\"\n---\n<p>Content</p>\n",
+		"toml": "+++\ntitle = \"Test\"\n+++\n<p>Content</p>\n",
+		"json": "{\n  \"title\": \"Test\"\n}\n<p>Content</p>\n",
+		"none": "\n\n\n<p>Content</p>\n",
+	} {
+		body, err := (&FrontMatter{}).parse([]byte(page))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+
+		if got := strings.TrimSpace(string(body.Raw())); got != "<p>Content</p>" {
+			t.Errorf("%s: got body %q", name, got)
+		}
+		if got := body.Line() + strings.Count(string(body.Raw()), "\n") - 1; got != 4 {
+			t.Errorf("%s: got last line %d", name, got)
+		}
+	}
+}
+
+func TestBody_HTML(t *testing.T) {
+	body := newBody([]byte("<p>Some <a href=\"/bbc/\">content</a></p>"), 0)
+	doc, err := body.HTML()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var find func(*html.Node) *html.Node
+	find = func(n *html.Node) *html.Node {
+		if n.Type == html.ElementNode && n.Data == "a" {
+			return n
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if a := find(c); a != nil {
+				return a
+			}
+		}
+		return nil
+	}
+
+	a := find(doc)
+	if a == nil || len(a.Attr) != 1 || a.Attr[0].Val != "/bbc/" {
+		t.Errorf("got %+v", a)
+	}
+}
+
+func TestBody_Shortcodes(t *testing.T) {
+	body := newBody([]byte(`---
+title: "Test"
+---
+<p>{{< book/link href="/bbc/" title="The \"BBC\"" >}}</p>
+{{% note info %}}
+Use {{</* book/link */>}} for links
+{{% /note %}}
+{{< figure
+    src=/static/a.png
+    caption=`+"`A figure`"+` >}}
+{{< m68k/effectiveAddress "(an)"="1" "-(an)"=1 "a=b" >}}
+`), 23)
+
+	var got []string
+	for _, sc := range body.Shortcodes() {
+		got = append(got, fmt.Sprintf("%d %s %v %q %v", sc.Line, sc.Name, sc.Markdown, sc.Args, sc.Params))
+	}
+
+	want := []string{
+		`4 book/link false [] map[href:/bbc/ title:The "BBC"]`,
+		`5 note true ["info"] map[]`,
+		`8 figure false [] map[caption:A figure src:/static/a.png]`,
+		`11 m68k/effectiveAddress false ["a=b"] map[(an):1 -(an):1]`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
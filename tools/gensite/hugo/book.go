@@ -1,11 +1,14 @@
 package hugo
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	strings2 "github.com/peter-mount/go-kernel/v2/util/strings"
 	"os"
 	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -37,11 +40,23 @@ type Predicate func(*Book) bool
 
 // Book defines a book that's rendered as pdf
 type Book struct {
-	BookCopyright `yaml:",inline"`     // Copyright of book
-	ID            string               `yaml:"id"`         // ID of the book, e.g. "bbc" or "6502"
-	FrontImage    BookCopyright        `yaml:"frontImage"` // Copyright of front image
-	Generate      strings2.StringSlice `yaml:"generate"`   // List of generators to run on this book
-	modified      time.Time            `yaml:"-"`          // Last Modified time
+	BookCopyright `yaml:",inline"`       // Copyright of book
+	ID            string                 `yaml:"id"`         // ID of the book, e.g. "bbc" or "6502"
+	FrontImage    BookCopyright          `yaml:"frontImage"` // Copyright of front image
+	Generate      strings2.StringSlice   `yaml:"generate"`   // List of generators to run on this book
+	PDF           string                 `yaml:"pdf"`        // Path of the book's pdf, see PDFFile
+	Enable        *bool                  `yaml:"enabled"`    // false to disable this book, see Enabled
+	Volumes       strings2.StringSlice   `yaml:"volumes"`    // Books by ID, or sections by path within content, included as volumes
+	Vars          map[string]interface{} `yaml:"vars"`       // Custom variables for Expand
+	volumes       Books                  `yaml:"-"`          // Resolved Volumes
+	section       bool                   `yaml:"-"`          // true if this is a section included as a volume, not a book
+	lang          *Language              `yaml:"-"`          // Language of the book
+	original      *Book                  `yaml:"-"`          // Book this is a translation of, nil if not a translation
+	translations  Books                  `yaml:"-"`          // Translations of this book
+	modified      time.Time              `yaml:"-"`          // Last Modified time
+	pages         map[string]string      `yaml:"-"`          // Hash of each page, keyed by path relative to contentPath
+	scanErr       error                  `yaml:"-"`          // Error reading the pages, if any
+	scanOnce      sync.Once              `yaml:"-"`          // Guards modified, pages & scanErr
 	contentPath   string
 	webPath       string
 }
@@ -54,6 +69,56 @@ type BookCopyright struct {
 	Copyright string `yaml:"copyright"` // Copyright
 }
 
+// init sets the ID & location of a book
+func (b *Book) init(id, contentPath string) {
+	b.ID = id
+	b.contentPath = contentPath
+	b.webPath = contentPath[strings.Index(contentPath, "/")+1:]
+}
+
+// apply the configuration of this book in BooksFile
+func (b *Book) apply(c *BookConfig) {
+	if c.PDF != "" {
+		b.PDF = c.PDF
+	}
+
+	if c.Enabled != nil {
+		b.Enable = c.Enabled
+	}
+
+	for _, g := range c.Generate {
+		if !b.generates(g) {
+			b.Generate = append(b.Generate, g)
+		}
+	}
+
+	if len(c.Volumes) > 0 {
+		b.Volumes = c.Volumes
+	}
+}
+
+func (b *Book) generates(g string) bool {
+	for _, e := range b.Generate {
+		if e == g {
+			return true
+		}
+	}
+	return false
+}
+
+// Enabled returns false if the book has been disabled, in which case nothing is generated for it
+func (b *Book) Enabled() bool {
+	return b.Enable == nil || *b.Enable
+}
+
+// PDFFile returns the path of the book's pdf
+func (b *Book) PDFFile() string {
+	if b.PDF != "" {
+		return b.PDF
+	}
+	return path.Join("public/static/book", b.ID+".pdf")
+}
+
 func (b *Book) ContentPath(s ...string) string {
 	if len(s) == 0 {
 		return b.contentPath
@@ -69,49 +134,225 @@ func (b *Book) StaticPath(suffix string) string {
 	return path.Join("static/static/book", b.ID+"_"+strings.ReplaceAll(suffix, "/", "_"))
 }
 
+// Modified returns the last modified time of the book's content
 func (b *Book) Modified() time.Time {
-	if b.modified.IsZero() {
-		_ = filepath.Walk(b.ContentPath(), func(_ string, info os.FileInfo, _ error) error {
-			if !info.IsDir() && info.ModTime().After(b.modified) {
-				b.modified = info.ModTime()
-			}
-			return nil
-		})
-	}
+	b.scan()
 	return b.modified
 }
 
-func replace(s, f string, h func() string) string {
-	if strings.Contains(s, f) {
-		return strings.ReplaceAll(s, f, h())
+// Pages returns the sha256 hash of each page in the book, keyed by it's path relative to the book.
+// An error is returned if any page could not be read, as the hashes would be incomplete.
+func (b *Book) Pages() (map[string]string, error) {
+	b.scan()
+	return b.pages, b.scanErr
+}
+
+// Refresh discards the modified time & page hashes so that they are recalculated, e.g. after the content has changed.
+func (b *Book) Refresh() {
+	b.scanOnce = sync.Once{}
+	b.modified = time.Time{}
+	b.pages = nil
+	b.scanErr = nil
+
+	for _, v := range b.volumes {
+		v.Refresh()
 	}
-	return s
 }
 
-func (b *Book) Expand(s string) string {
-	// Modified date of content
-	s = replace(s, "${modified}", func() string {
-		return b.Modified().Format(time.RFC1123)
-	})
+// Language returns the language of the book, nil if the book has not been added to a BookShelf
+func (b *Book) Language() *Language {
+	return b.lang
+}
+
+// Translation returns true if this book is the translation of another
+func (b *Book) Translation() bool {
+	return b.original != nil
+}
+
+// Original returns the book this is a translation of, or this book if it is not a translation
+func (b *Book) Original() *Book {
+	if b.original != nil {
+		return b.original
+	}
+	return b
+}
+
+// Translations returns the translations of this book
+func (b *Book) Translations() Books {
+	return b.translations
+}
+
+// I18n returns the translation of id into the book's language, other if there is none.
+// Generators use this for any text they write into pages.
+func (b *Book) I18n(id, other string) string {
+	return b.lang.I18n(id, other)
+}
 
-	// Expand book title or use default from chrome
-	s = replace(s, "${title}", func() string {
-		t := b.Title
-		if t == "" {
-			t = "<span class='title'></span>"
+// PageName returns the name of a page in the book's language, e.g. "_index.html" is "_index.fr.html"
+func (b *Book) PageName(name string) string {
+	return b.lang.PageName(name)
+}
+
+// PageURL returns the path on the site of a page in the book, as hugo would generate it, so
+// "content/asm/6502/opcodes/adc.html" is "/asm/6502/opcodes/adc/" & in a French translation "/fr/asm/6502/opcodes/adc/".
+// Pages of the original book, used by a translation where there is no translated page, are also in the translation.
+func (b *Book) PageURL(pathName string) string {
+	rel := pathName
+	for _, o := range []*Book{b, b.Original()} {
+		if strings.HasPrefix(pathName, o.contentPath+"/") {
+			rel = strings.TrimPrefix(pathName, o.contentPath+"/")
+			break
 		}
-		return strings.ReplaceAll(s, "${title}", t)
-	})
+	}
 
-	s = replace(s, "${author}", func() string {
-		return b.Author
-	})
+	dir, name := path.Split(rel)
+	name = strings.TrimSuffix(name, path.Ext(name))
+	if b.Translation() {
+		name = strings.TrimSuffix(name, "."+b.lang.Code)
+	}
+	if name != "_index" && name != "index" {
+		dir = path.Join(dir, name)
+	}
 
-	s = replace(s, "${copyright}", func() string {
-		return b.Copyright
-	})
+	p := path.Join("/", b.webPath, dir)
+	if b.Translation() {
+		p = path.Join("/", b.lang.Code, p)
+	}
+	return strings.ToLower(strings.TrimSuffix(p, "/") + "/")
+}
+
+// translate returns a translation of this book into another language, with its content in contentPath.
+// The title & copyright are taken from the front matter of the translated index page, fm, if it has any.
+func (b *Book) translate(lang *Language, contentPath string, fm *FrontMatter) *Book {
+	t := &Book{
+		BookCopyright: b.BookCopyright,
+		FrontImage:    b.FrontImage,
+		Generate:      b.Generate,
+		Enable:        b.Enable,
+		Vars:          b.Vars,
+		volumes:       b.volumes,
+		lang:          lang,
+		original:      b,
+	}
+
+	if b.PDF != "" {
+		ext := path.Ext(b.PDF)
+		t.PDF = strings.TrimSuffix(b.PDF, ext) + "." + lang.Code + ext
+	}
+
+	if fm != nil {
+		if fm.Book != nil {
+			t.BookCopyright.merge(fm.Book.BookCopyright)
+		} else if fm.Title != "" {
+			t.Title = fm.Title
+		}
+	}
 
-	return s
+	t.init(b.ID+"."+lang.Code, contentPath)
+	// The book is at the same location within the site, only in another language
+	t.webPath = b.webPath
+	return t
+}
+
+// merge the non-empty fields of another BookCopyright into this one
+func (c *BookCopyright) merge(o BookCopyright) {
+	for _, e := range []struct {
+		dst *string
+		src string
+	}{
+		{&c.Title, o.Title},
+		{&c.SubTitle, o.SubTitle},
+		{&c.Author, o.Author},
+		{&c.SubAuthor, o.SubAuthor},
+		{&c.Copyright, o.Copyright},
+	} {
+		if e.src != "" {
+			*e.dst = e.src
+		}
+	}
+}
+
+// VolumeBooks returns the books & sections included in this book as volumes, in the order they were declared
+func (b *Book) VolumeBooks() Books {
+	return b.volumes
+}
+
+// Includes returns true if a book is a volume of this book, or of one of its volumes
+func (b *Book) Includes(o *Book) bool {
+	for _, v := range b.volumes {
+		if v == o || v.Includes(o) {
+			return true
+		}
+	}
+	return false
+}
+
+// PrintPaths returns the web paths printed in the book's pdf, the book's own followed by those of its volumes
+// in the order they were declared
+func (b *Book) PrintPaths() []string {
+	paths := []string{b.WebPath()}
+	for _, v := range b.volumes {
+		paths = append(paths, v.PrintPaths()...)
+	}
+	return paths
+}
+
+// Section returns true if this is a section of content included as a volume rather than a book
+func (b *Book) Section() bool {
+	return b.section
+}
+
+// scan walks the book's content, ignoring any generated reference pages, recording the latest modified time
+// and the hash of each page.
+//
+// The pages of each volume are included, prefixed by the volume's ID, as a book depends on its volumes.
+func (b *Book) scan() {
+	b.scanOnce.Do(func() {
+		b.pages = make(map[string]string)
+
+		for _, v := range b.volumes {
+			if v.Modified().After(b.modified) {
+				b.modified = v.Modified()
+			}
+			pages, err := v.Pages()
+			if err != nil {
+				b.scanErr = err
+				return
+			}
+			for k, h := range pages {
+				b.pages[v.ID+":"+k] = h
+			}
+		}
+
+		b.scanErr = filepath.Walk(b.ContentPath(), func(fileName string, info os.FileInfo, err error) error {
+			switch {
+			case err != nil:
+				// A book without content of it's own, e.g. one made of volumes
+				if os.IsNotExist(err) && fileName == b.ContentPath() {
+					return nil
+				}
+				return err
+			case info.IsDir():
+				if info.Name() == "reference" {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			if info.ModTime().After(b.modified) {
+				b.modified = info.ModTime()
+			}
+
+			buf, err := os.ReadFile(fileName)
+			if err != nil {
+				return err
+			}
+
+			h := sha256.Sum256(buf)
+			b.pages[strings.TrimPrefix(fileName, b.ContentPath()+"/")] = hex.EncodeToString(h[:])
+			return nil
+		})
+	})
 }
 
 // Do runs a function against this instance. When it exits it removes any resources the Book has used freeing up memory.
@@ -1,29 +1,91 @@
 package hugo
 
 import (
+	"fmt"
 	"github.com/peter-mount/go-kernel/v2/log"
-	"github.com/peter-mount/go-kernel/v2/util/walk"
+	strings2 "github.com/peter-mount/go-kernel/v2/util/strings"
+	"gopkg.in/yaml.v2"
 	"os"
 	"path"
+	"sort"
 	"strings"
 )
 
+const (
+	BooksFile = "books.yaml" // Per-book configuration
+)
+
 // BookShelf manages all Book's.
+//
+// A book is declared by the book key in the front matter of its _index.html page. It can also be declared in
+// BooksFile by giving the path of that page within content.
+//
+// The front matter describes the book whilst BooksFile describes how it's built. Entries in BooksFile override the
+// pdf, enabled state & volumes of the book & add to the generators it lists.
+//
+// A book can include other books, or sections of content, as volumes, e.g. an omnibus of several CPU books.
+// A section is generated as a book in its own right using the generators of the book including it.
+//
+// A book with content in another of the site's Languages has a translation, a book with the ID "<id>.<language>",
+// e.g. "bbcMos.fr". Its pages are the translated ones, falling back to those in the default language.
 type BookShelf struct {
-	books Books
+	languages *Languages `kernel:"inject"` // Languages of the site
+	books     Books
+}
+
+// BookConfig is the configuration of a book in BooksFile
+type BookConfig struct {
+	Path     string               `yaml:"path"`     // Path of the book within content, defaults to the book with the same ID
+	PDF      string               `yaml:"pdf"`      // Path of the book's pdf
+	Enabled  *bool                `yaml:"enabled"`  // false to disable the book
+	Generate strings2.StringSlice `yaml:"generate"` // Generators to run in addition to those in the front matter
+	Volumes  strings2.StringSlice `yaml:"volumes"`  // Volumes of the book, replacing those in the front matter
+}
+
+// booksFile is the format of BooksFile
+type booksFile struct {
+	Books map[string]*BookConfig `yaml:"books"`
 }
 
 func (bs *BookShelf) Start() error {
 	log.Println("Searching for books")
-	return walk.NewPathWalker().
-		IsFile().
-		PathNotContain("/reference/").
-		PathHasSuffix("/_index.html").
-		Then(bs.scanPage).
-		Walk("content")
+	err := walkPages("content", "/_index.html", bs.scanPage)
+	if err != nil {
+		return err
+	}
+
+	config, err := loadBooksFile(BooksFile)
+	if err != nil {
+		return fmt.Errorf("%s: %w", BooksFile, err)
+	}
+
+	if err = bs.merge(config, loadIndexPage); err != nil {
+		return fmt.Errorf("%s: %w", BooksFile, err)
+	}
+
+	if err = bs.resolveVolumes(loadIndexPage); err != nil {
+		return err
+	}
+
+	if err = bs.translate(); err != nil {
+		return err
+	}
+
+	for _, b := range bs.books {
+		if !b.Enabled() {
+			log.Println("Disabled", b.ID)
+		}
+	}
+
+	return nil
 }
 
-func (bs *BookShelf) scanPage(pathName string, _ os.FileInfo) error {
+func (bs *BookShelf) scanPage(pathName string, info os.FileInfo) error {
+	// Translated content is found by translate
+	if info.IsDir() || bs.translatedContent(pathName) {
+		return nil
+	}
+
 	fm := FrontMatter{}
 	err := fm.LoadFrontMatter(pathName)
 	if err != nil {
@@ -31,9 +93,12 @@ func (bs *BookShelf) scanPage(pathName string, _ os.FileInfo) error {
 	}
 
 	if fm.Book != nil {
-		fm.Book.ID = path.Base(path.Dir(pathName))
-		fm.Book.contentPath = pathName[strings.LastIndex(pathName, "content/"):strings.LastIndex(pathName, "/")]
-		fm.Book.webPath = fm.Book.contentPath[strings.Index(fm.Book.contentPath, "/")+1:]
+		id := path.Base(path.Dir(pathName))
+		if b := bs.book(id); b != nil {
+			return fmt.Errorf("book %s is defined in both %s and %s", id, b.ContentPath(), path.Dir(pathName))
+		}
+
+		fm.Book.init(id, pathName[strings.LastIndex(pathName, "content/"):strings.LastIndex(pathName, "/")])
 		log.Println("Found", fm.Book.ID)
 
 		bs.books = append(bs.books, fm.Book)
@@ -42,6 +107,245 @@ func (bs *BookShelf) scanPage(pathName string, _ os.FileInfo) error {
 	return nil
 }
 
+// loadBooksFile loads BooksFile, returning no configuration if it does not exist
+func loadBooksFile(fileName string) (map[string]*BookConfig, error) {
+	buf, err := os.ReadFile(fileName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	f := &booksFile{}
+	if err := yaml.Unmarshal(buf, f); err != nil {
+		return nil, err
+	}
+	return f.Books, nil
+}
+
+// loadIndexPage loads the front matter of the _index.html page of a directory
+func loadIndexPage(contentPath string) (*FrontMatter, error) {
+	fm := &FrontMatter{}
+	if err := fm.LoadFrontMatter(path.Join(contentPath, "_index.html")); err != nil {
+		return nil, err
+	}
+	return fm, nil
+}
+
+// merge the configuration from BooksFile into the books found in the content.
+// Books only declared in BooksFile are loaded with load, appended in ID order.
+func (bs *BookShelf) merge(config map[string]*BookConfig, load func(contentPath string) (*FrontMatter, error)) error {
+	var ids []string
+	for id := range config {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		c := config[id]
+		if c == nil {
+			c = &BookConfig{}
+		}
+
+		b := bs.book(id)
+
+		if c.Path != "" {
+			contentPath := path.Join("content", path.Clean(c.Path))
+
+			if b == nil {
+				if o := bs.bookAt(contentPath); o != nil {
+					return fmt.Errorf("book %s has path %s which is book %s", id, c.Path, o.ID)
+				}
+
+				fm, err := load(contentPath)
+				if err != nil {
+					return fmt.Errorf("book %s: %w", id, err)
+				}
+
+				b = fm.Book
+				if b == nil {
+					b = &Book{}
+				}
+				if b.Title == "" {
+					b.Title = fm.Title
+				}
+				b.init(id, contentPath)
+				log.Println("Found", b.ID)
+
+				bs.books = append(bs.books, b)
+			} else if b.ContentPath() != contentPath {
+				return fmt.Errorf("book %s has path %s but is in %s", id, c.Path, b.ContentPath())
+			}
+		}
+
+		if b == nil {
+			return fmt.Errorf("book %s does not exist, it needs a path", id)
+		}
+
+		b.apply(c)
+	}
+
+	return nil
+}
+
+// resolveVolumes resolves the volumes of each enabled book, adding any sections to the shelf
+func (bs *BookShelf) resolveVolumes(load func(contentPath string) (*FrontMatter, error)) error {
+	// Copy as sections are appended
+	for _, b := range append(Books{}, bs.books...) {
+		if !b.Enabled() {
+			continue
+		}
+
+		for _, name := range b.Volumes {
+			v, err := bs.volume(b, name, load)
+			if err != nil {
+				return fmt.Errorf("book %s volume %s: %w", b.ID, name, err)
+			}
+			b.volumes = append(b.volumes, v)
+		}
+	}
+
+	for _, b := range bs.books {
+		if err := checkVolumes(b, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkVolumes returns an error if a book is one of its own volumes
+func checkVolumes(b *Book, parents Books) error {
+	for _, p := range parents {
+		if p == b {
+			return fmt.Errorf("book %s includes itself as a volume", b.ID)
+		}
+	}
+
+	for _, v := range b.volumes {
+		if err := checkVolumes(v, append(parents, b)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// volume returns the Book for a volume, a book ID or a section's path within content
+func (bs *BookShelf) volume(b *Book, name string, load func(contentPath string) (*FrontMatter, error)) (*Book, error) {
+	if !strings.Contains(name, "/") {
+		v := bs.book(name)
+		if v == nil {
+			return nil, fmt.Errorf("book does not exist")
+		}
+		if !v.Enabled() {
+			return nil, fmt.Errorf("book is disabled")
+		}
+		return v, nil
+	}
+
+	contentPath := path.Join("content", path.Clean(name))
+	if v := bs.bookAt(contentPath); v != nil {
+		return v, nil
+	}
+
+	fm, err := load(contentPath)
+	if err != nil {
+		return nil, err
+	}
+
+	id := strings.ReplaceAll(path.Clean(name), "/", "-")
+	if bs.book(id) != nil {
+		return nil, fmt.Errorf("section ID %s is already used by a book", id)
+	}
+
+	v := &Book{
+		BookCopyright: b.BookCopyright,
+		Generate:      b.Generate,
+		section:       true,
+	}
+	v.Title = fm.Title
+	v.SubTitle = ""
+	v.init(id, contentPath)
+	log.Println("Found section", v.ID)
+
+	bs.books = append(bs.books, v)
+	return v, nil
+}
+
+// translatedContent returns true if a path is within the content directory of a translation
+func (bs *BookShelf) translatedContent(pathName string) bool {
+	for _, lang := range bs.languages.Translations() {
+		if lang.ContentDir != "" && strings.HasPrefix(pathName, lang.ContentDir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// translate adds the translations of each enabled book for every language it has content in
+func (bs *BookShelf) translate() error {
+	// Copy as translations are appended
+	for _, b := range append(Books{}, bs.books...) {
+		b.lang = bs.languages.Default()
+		if !b.Enabled() || b.Section() {
+			continue
+		}
+
+		for _, lang := range bs.languages.Translations() {
+			contentPath := b.ContentPath()
+			if lang.ContentDir != "" {
+				contentPath = path.Join(lang.ContentDir, b.WebPath())
+			}
+
+			if !bs.languages.hasContent(contentPath, lang) {
+				continue
+			}
+
+			fm := &FrontMatter{}
+			if err := fm.LoadFrontMatter(path.Join(contentPath, lang.PageName("_index.html"))); err != nil {
+				if !os.IsNotExist(err) {
+					return err
+				}
+				fm = nil
+			}
+
+			t := b.translate(lang, contentPath, fm)
+			if bs.book(t.ID) != nil {
+				return fmt.Errorf("translation %s has the same ID as a book", t.ID)
+			}
+			log.Println("Found translation", t.ID)
+
+			b.translations = append(b.translations, t)
+			bs.books = append(bs.books, t)
+		}
+	}
+	return nil
+}
+
+// book returns the Book with an ID, nil if none
+func (bs *BookShelf) book(id string) *Book {
+	for _, b := range bs.books {
+		if b.ID == id {
+			return b
+		}
+	}
+	return nil
+}
+
+// bookAt returns the Book with a content path, nil if none
+func (bs *BookShelf) bookAt(contentPath string) *Book {
+	for _, b := range bs.books {
+		if b.ContentPath() == contentPath {
+			return b
+		}
+	}
+	return nil
+}
+
+// Books returns the enabled books
 func (bs *BookShelf) Books() Books {
-	return bs.books
+	return bs.books.Filter(func(b *Book) bool {
+		return b.Enabled()
+	})
 }
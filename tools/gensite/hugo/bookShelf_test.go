@@ -0,0 +1,134 @@
+package hugo
+
+import (
+	"fmt"
+	"github.com/peter-mount/go-kernel/v2/util/strings"
+	"testing"
+)
+
+func newTestShelf() *BookShelf {
+	bs := &BookShelf{}
+	for _, id := range []string{"6502", "bbcMos"} {
+		b := &Book{Generate: strings.Of("autodoc")}
+		b.init(id, map[string]string{"6502": "content/asm/6502", "bbcMos": "content/bbc/bbcMos"}[id])
+		bs.books = append(bs.books, b)
+	}
+	return bs
+}
+
+func testLoader(contentPath string) (*FrontMatter, error) {
+	switch contentPath {
+	case "content/c64/basic":
+		return &FrontMatter{Title: "C64 Basic"}, nil
+	case "content/asm/6502":
+		return &FrontMatter{Book: &Book{}}, nil
+	default:
+		return nil, fmt.Errorf("%s not found", contentPath)
+	}
+}
+
+func TestBookShelf_merge(t *testing.T) {
+	disabled := false
+	bs := newTestShelf()
+	err := bs.merge(map[string]*BookConfig{
+		"6502":   {Path: "asm/6502", PDF: "public/books/6502.pdf", Generate: strings.Of("autodoc", "6502Opcodes")},
+		"bbcMos": {Enabled: &disabled},
+		"basic":  {Path: "c64/basic"},
+	}, testLoader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+	for _, b := range bs.Books() {
+		ids = append(ids, b.ID)
+	}
+	if got := fmt.Sprint(ids); got != "[6502 basic]" {
+		t.Errorf("got books %s", got)
+	}
+
+	b := bs.book("6502")
+	if got := b.Generate.Join(","); got != "autodoc,6502Opcodes" {
+		t.Errorf("got generate %q", got)
+	}
+	if b.PDFFile() != "public/books/6502.pdf" {
+		t.Errorf("got pdf %q", b.PDFFile())
+	}
+
+	b = bs.book("basic")
+	if b.Title != "C64 Basic" || b.ContentPath() != "content/c64/basic" || b.WebPath() != "c64/basic" {
+		t.Errorf("got basic %q %q %q", b.Title, b.ContentPath(), b.WebPath())
+	}
+	if b.PDFFile() != "public/static/book/basic.pdf" {
+		t.Errorf("got pdf %q", b.PDFFile())
+	}
+}
+
+func TestBookShelf_merge_conflicts(t *testing.T) {
+	for name, config := range map[string]*BookConfig{
+		"6502":   {Path: "asm/65c02"},  // Path differs from the front matter
+		"z80":    {},                   // Not in the content
+		"mos":    {Path: "bbc/bbcMos"}, // Path of a different book
+		"commie": {Path: "c64/kernal"}, // No such page
+	} {
+		bs := newTestShelf()
+		if err := bs.merge(map[string]*BookConfig{name: config}, testLoader); err == nil {
+			t.Errorf("%s expected error", name)
+		}
+	}
+}
+
+func TestBookShelf_resolveVolumes(t *testing.T) {
+	bs := newTestShelf()
+	omnibus := &Book{Generate: strings.Of("volumeIndex"), Volumes: strings.Of("6502", "c64/basic")}
+	omnibus.init("omnibus", "content/omnibus")
+	bs.books = append(bs.books, omnibus)
+
+	if err := bs.resolveVolumes(testLoader); err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+	for _, v := range omnibus.VolumeBooks() {
+		ids = append(ids, v.ID)
+	}
+	if got := fmt.Sprint(ids); got != "[6502 c64-basic]" {
+		t.Errorf("got volumes %s", got)
+	}
+
+	section := bs.book("c64-basic")
+	if section == nil || !section.Section() || section.Title != "C64 Basic" || section.Generate.Join(",") != "volumeIndex" {
+		t.Errorf("got section %+v", section)
+	}
+
+	if !omnibus.Includes(section) || bs.book("6502").Includes(omnibus) {
+		t.Error("Includes")
+	}
+
+	if got := fmt.Sprint(omnibus.PrintPaths()); got != "[omnibus asm/6502 c64/basic]" {
+		t.Errorf("got print paths %s", got)
+	}
+}
+
+func TestBookShelf_resolveVolumes_errors(t *testing.T) {
+	disabled := false
+	for name, volumes := range map[string]map[string][]string{
+		"missing":  {"6502": {"z80"}},
+		"disabled": {"6502": {"bbcMos"}},
+		"section":  {"6502": {"c64/kernal"}},
+		"self":     {"6502": {"6502"}},
+		"cycle":    {"6502": {"bbcMos"}, "bbcMos": {"6502"}},
+	} {
+		bs := newTestShelf()
+		if name == "disabled" {
+			bs.book("bbcMos").Enable = &disabled
+		}
+		for id, v := range volumes {
+			bs.book(id).Volumes = v
+		}
+
+		if err := bs.resolveVolumes(testLoader); err == nil {
+			t.Errorf("%s expected error", name)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package hugo
+
+import (
+	"testing"
+)
+
+func TestBook_PageURL(t *testing.T) {
+	l := testLanguages(t, `
+[languages.en]
+[languages.fr]
+[languages.de]
+contentDir = "content/de"
+`)
+
+	b := &Book{lang: l.Default()}
+	b.init("6502", "content/asm/6502")
+	fr := b.translate(l.Get("fr"), b.ContentPath(), nil)
+	de := b.translate(l.Get("de"), "content/de/asm/6502", nil)
+
+	for _, test := range []struct {
+		book     *Book
+		pathName string
+		want     string
+	}{
+		{b, "content/asm/6502/_index.html", "/asm/6502/"},
+		{b, "content/asm/6502/opcodes/ADC.html", "/asm/6502/opcodes/adc/"},
+		{b, "content/asm/6502/opcodes/_index.html", "/asm/6502/opcodes/"},
+		{b, "content/asm/6502/intro/index.md", "/asm/6502/intro/"},
+		{fr, "content/asm/6502/opcodes/adc.fr.html", "/fr/asm/6502/opcodes/adc/"},
+		{fr, "content/asm/6502/opcodes/_index.fr.html", "/fr/asm/6502/opcodes/"},
+		{de, "content/de/asm/6502/opcodes/adc.html", "/de/asm/6502/opcodes/adc/"},
+		{de, "content/asm/6502/opcodes/sbc.html", "/de/asm/6502/opcodes/sbc/"},
+	} {
+		if got := test.book.PageURL(test.pathName); got != test.want {
+			t.Errorf("%s %s got %q want %q", test.book.ID, test.pathName, got, test.want)
+		}
+	}
+}
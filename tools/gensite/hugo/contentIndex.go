@@ -0,0 +1,210 @@
+package hugo
+
+import (
+	"context"
+	"fmt"
+	"github.com/peter-mount/go-kernel/v2/log"
+	"github.com/peter-mount/go-kernel/v2/util/walk"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ContentIndex holds the parsed pages of each book so that the front matter of a page is read once per run,
+// no matter how many generators use it.
+//
+// The pages of a book are read when first requested & held until Release is called for that book.
+//
+// A book only has the pages in its own language. A translation uses the default language version of any page
+// which has not been translated.
+//
+// Pages which hugo will not build, e.g. drafts, are excluded so generated pages do not link to them.
+type ContentIndex struct {
+	languages *Languages `kernel:"inject"` // Languages of the site
+	hugo      *Hugo      `kernel:"inject"` // For the pages hugo will build
+	mutex     sync.Mutex
+	books     map[string]*bookIndex
+	changes   map[string]time.Time // When the pages hugo builds next change for each book
+}
+
+// bookIndex is the parsed pages of a book
+type bookIndex struct {
+	once  sync.Once
+	pages []*page
+	err   error
+}
+
+// page is a parsed page within a book
+type page struct {
+	path string
+	info os.FileInfo
+	fm   *FrontMatter
+	body *Body
+}
+
+func (ci *ContentIndex) Start() error {
+	ci.books = make(map[string]*bookIndex)
+	ci.changes = make(map[string]time.Time)
+	return nil
+}
+
+// Walk calls a FrontMatterAction for each page within a book, in the same way as FrontMatterAction.Walk would,
+// e.g. FrontMatterActionOf().OtherExists("chip", f) would call f for every page defining chips.
+//
+// Generators must treat the FrontMatter as read only, as it is shared with any other generator for the book.
+func (ci *ContentIndex) Walk(ctx context.Context, book *Book, a FrontMatterAction) error {
+	pages, err := ci.pages(book)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range pages {
+		if err := a.Do(pageContext(ctx, p.path, p.info, p.body), p.fm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NextChange returns when the pages hugo builds for a book will next change, as a page is published or expires.
+// This is the zero time if they will not change or the book has not been walked.
+func (ci *ContentIndex) NextChange(book *Book) time.Time {
+	ci.mutex.Lock()
+	defer ci.mutex.Unlock()
+	return ci.changes[book.ID]
+}
+
+// Release discards the pages held for a book, they will be read again if the book is walked again.
+func (ci *ContentIndex) Release(book *Book) {
+	ci.mutex.Lock()
+	defer ci.mutex.Unlock()
+	delete(ci.books, book.ID)
+}
+
+// pages returns the pages of a book, reading them if required
+func (ci *ContentIndex) pages(book *Book) ([]*page, error) {
+	ci.mutex.Lock()
+	bi, exists := ci.books[book.ID]
+	if !exists {
+		bi = &bookIndex{}
+		ci.books[book.ID] = bi
+	}
+	ci.mutex.Unlock()
+
+	bi.once.Do(func() {
+		bi.pages, bi.err = ci.readPages(book)
+	})
+	return bi.pages, bi.err
+}
+
+// Files returns the pages of a book in its language, in the order they are walked
+func (ci *ContentIndex) Files(book *Book) ([]string, error) {
+	original := book.Original()
+	lang := book.Language()
+
+	var files []string
+	index := make(map[string]int) // Index in files by page name without the language
+
+	// add a page, a translation replacing the default language version
+	add := func(name, fileName string, isTranslation bool) {
+		if i, exists := index[name]; !exists {
+			index[name] = len(files)
+			files = append(files, fileName)
+		} else if isTranslation {
+			files[i] = fileName
+		}
+	}
+
+	walkBook := func(dir string, f func(name, fileName string)) error {
+		return walkPages(dir, ".html", func(fileName string, _ os.FileInfo) error {
+			f(strings.TrimPrefix(fileName, dir+"/"), fileName)
+			return nil
+		})
+	}
+
+	err := walkBook(original.ContentPath(), func(name, fileName string) {
+		n, l := ci.languages.split(name)
+		switch {
+		case l == nil || l.Default:
+			add(n, fileName, false)
+		case book.Translation() && l == lang && lang.ContentDir == "":
+			add(n, fileName, true)
+		}
+	})
+
+	if err == nil && book.Translation() && lang.ContentDir != "" {
+		err = walkBook(book.ContentPath(), func(name, fileName string) {
+			add(name, fileName, true)
+		})
+	}
+
+	return files, err
+}
+
+// walkPages calls f for each page under dir whose name ends with suffix, excluding any generated reference pages.
+//
+// Note: a walk.PathWalker's predicates apply to the walker they follow, so here they come after Then
+// to filter which pages f is called for.
+func walkPages(dir, suffix string, f func(string, os.FileInfo) error) error {
+	return walk.NewPathWalker().
+		Then(f).
+		PathNotContain("/reference/").
+		PathHasSuffix(suffix).
+		IsFile().
+		Walk(dir)
+}
+
+// readPages reads the pages of a book
+func (ci *ContentIndex) readPages(book *Book) ([]*page, error) {
+	log.Printf("Indexing %s", book.ID)
+
+	files, err := ci.Files(book)
+	if err != nil {
+		return nil, err
+	}
+
+	var options BuildOptions
+	if ci.hugo != nil {
+		options = ci.hugo.BuildOptions()
+	}
+	now := time.Now()
+
+	var pages []*page
+	var next time.Time
+	for _, fileName := range files {
+		info, err := os.Stat(fileName)
+		if err != nil {
+			return nil, err
+		}
+
+		buf, err := os.ReadFile(fileName)
+		if err != nil {
+			return nil, err
+		}
+
+		p := &page{path: fileName, info: info, fm: &FrontMatter{}}
+		if p.body, err = p.fm.parse(buf); err != nil {
+			return nil, fmt.Errorf("%s: %w", fileName, err)
+		}
+
+		for _, t := range []time.Time{p.fm.Published(), p.fm.ExpiryDate.Time} {
+			if t.After(now) && (next.IsZero() || t.Before(next)) {
+				next = t
+			}
+		}
+
+		if !options.Builds(p.fm, now) {
+			log.Printf("Excluding %s as hugo will not build it", fileName)
+			continue
+		}
+
+		pages = append(pages, p)
+	}
+
+	ci.mutex.Lock()
+	ci.changes[book.ID] = next
+	ci.mutex.Unlock()
+
+	return pages, nil
+}
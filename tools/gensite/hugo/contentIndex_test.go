@@ -0,0 +1,113 @@
+package hugo
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestContentIndex_Walk(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"_index.html":                 "---\ntitle: \"Book\"\n---\n",
+		"chips/_index.html":           "---\ntitle: \"Chips\"\nchip:\n  - name: \"6502\"\n---\n",
+		"chips/image.png":             "---\nchip: []\n---\n",
+		"reference/chips/_index.html": "---\nchip: []\n---\n",
+	} {
+		fileName := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fileName, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	book := &Book{}
+	book.init("test", dir)
+
+	ci := &ContentIndex{}
+	_ = ci.Start()
+
+	walkBook := func() (string, string) {
+		var titles, chips []string
+		err := ci.Walk(context.Background(), book, FrontMatterActionOf(
+			func(_ context.Context, fm *FrontMatter) error {
+				titles = append(titles, fm.Title)
+				return nil
+			}).
+			OtherExists("chip", func(ctx context.Context, _ *FrontMatter) error {
+				chips = append(chips, strings.TrimPrefix(FilePath(ctx), dir))
+				return nil
+			}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sort.Strings(titles)
+		return strings.Join(titles, ","), strings.Join(chips, ",")
+	}
+
+	if titles, chips := walkBook(); titles != "Book,Chips" || chips != "/chips/_index.html" {
+		t.Errorf("got %q %q", titles, chips)
+	}
+
+	// Pages are only read again once released
+	if err := os.WriteFile(filepath.Join(dir, "_index.html"), []byte("---\ntitle: \"Changed\"\n---\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if titles, _ := walkBook(); titles != "Book,Chips" {
+		t.Errorf("got %q before release", titles)
+	}
+
+	ci.Release(book)
+	if titles, _ := walkBook(); titles != "Changed,Chips" {
+		t.Errorf("got %q after release", titles)
+	}
+}
+
+func TestContentIndex_Files_translation(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"book/_index.html", "book/_index.fr.html", "book/a.html", "book/b.html", "book/b.fr.html",
+		"book/reference/_index.fr.html", "de/book/a.html", "de/book/c.html",
+	} {
+		fileName := filepath.Join(dir, name)
+		_ = os.MkdirAll(filepath.Dir(fileName), 0755)
+		if err := os.WriteFile(fileName, []byte("<p>Content</p>\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	l := testLanguages(t, `
+[languages.fr]
+[languages.de]
+contentDir = "`+filepath.Join(dir, "de")+`"
+`)
+	ci := &ContentIndex{languages: l}
+
+	book := &Book{lang: l.Default()}
+	book.init("book", filepath.Join(dir, "book"))
+
+	for _, test := range []struct {
+		book *Book
+		want string
+	}{
+		{book, "book/_index.html book/a.html book/b.html"},
+		{book.translate(l.Get("fr"), book.ContentPath(), nil), "book/_index.fr.html book/a.html book/b.fr.html"},
+		{book.translate(l.Get("de"), filepath.Join(dir, "de/book"), nil), "book/_index.html de/book/a.html book/b.html de/book/c.html"},
+	} {
+		files, err := ci.Files(test.book)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, f := range files {
+			files[i] = strings.TrimPrefix(f, dir+"/")
+		}
+		if got := strings.Join(files, " "); got != test.want {
+			t.Errorf("%s got %q want %q", test.book.ID, got, test.want)
+		}
+	}
+}
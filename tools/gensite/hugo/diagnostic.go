@@ -0,0 +1,142 @@
+package hugo
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	LevelError   = "error"
+	LevelWarning = "warning"
+)
+
+// Diagnostic is an error or warning reported by hugo
+type Diagnostic struct {
+	Level   string // LevelError or LevelWarning
+	File    string // File the problem is in, "" if unknown
+	Line    int    // Line in File, 0 if unknown
+	Message string // The message from hugo
+}
+
+func (d *Diagnostic) String() string {
+	switch {
+	case d.File != "" && d.Line > 0:
+		return fmt.Sprintf("%s:%d: %s: %s", d.File, d.Line, d.Level, d.Message)
+	case d.File != "":
+		return fmt.Sprintf("%s: %s: %s", d.File, d.Level, d.Message)
+	default:
+		return d.Level + ": " + d.Message
+	}
+}
+
+var (
+	// Level, optional timestamp & message of a line hugo logs
+	diagnosticRegex = regexp.MustCompile(`^(ERROR|Error:|WARN|WARNING|Warning:)\s+(?:\d{4}/\d\d/\d\d \d\d:\d\d:\d\d\s+)?(.*)$`)
+	// Position within a file, e.g. "layouts/_default/single.html:12:5"
+	positionRegex = regexp.MustCompile(`((?:[A-Za-z]:)?[^\s"':]*\.[A-Za-z0-9]+):(\d+)(?::\d+)?`)
+)
+
+// parseDiagnostic parses a line of hugo's output, returning nil if it is not an error or warning.
+// As hugo wraps errors, the position used is the last one in the message as that is where the problem is.
+func parseDiagnostic(line string) *Diagnostic {
+	m := diagnosticRegex.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return nil
+	}
+
+	d := &Diagnostic{Level: LevelWarning, Message: strings.TrimSpace(m[2])}
+	if strings.HasPrefix(strings.ToUpper(m[1]), "ERROR") {
+		d.Level = LevelError
+	}
+
+	if p := positionRegex.FindAllStringSubmatch(d.Message, -1); len(p) > 0 {
+		d.File = p[len(p)-1][1]
+		d.Line, _ = strconv.Atoi(p[len(p)-1][2])
+	}
+
+	return d
+}
+
+// diagnostics is an io.Writer which collects the Diagnostic's in hugo's output, optionally passing it on to out.
+type diagnostics struct {
+	mutex sync.Mutex
+	out   io.Writer     // Writer to pass the output to, nil for none
+	dir   string        // Directory paths are made relative to
+	buf   []byte        // Incomplete line
+	tail  []string      // Last lines of output
+	list  []*Diagnostic // Diagnostics found
+}
+
+// Number of lines of output kept for when hugo fails without a recognisable error
+const diagnosticsTail = 20
+
+func (d *diagnostics) Write(p []byte) (int, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.out != nil {
+		if _, err := d.out.Write(p); err != nil {
+			return 0, err
+		}
+	}
+
+	d.buf = append(d.buf, p...)
+	for {
+		i := bytes.IndexByte(d.buf, '\n')
+		if i < 0 {
+			break
+		}
+		d.line(string(bytes.TrimRight(d.buf[:i], "\r")))
+		d.buf = d.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Close processes any incomplete line at the end of the output
+func (d *diagnostics) Close() error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if len(d.buf) > 0 {
+		d.line(string(d.buf))
+		d.buf = nil
+	}
+	return nil
+}
+
+func (d *diagnostics) line(line string) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+
+	d.tail = append(d.tail, line)
+	if len(d.tail) > diagnosticsTail {
+		d.tail = d.tail[1:]
+	}
+
+	if diag := parseDiagnostic(line); diag != nil {
+		if d.dir != "" && filepath.IsAbs(diag.File) {
+			if rel, err := filepath.Rel(d.dir, diag.File); err == nil && !strings.HasPrefix(rel, "..") {
+				diag.File = rel
+			}
+		}
+		d.list = append(d.list, diag)
+	}
+}
+
+// count returns the number of diagnostics at a level
+func (d *diagnostics) count(level string) int {
+	n := 0
+	for _, e := range d.list {
+		if e.Level == level {
+			n++
+		}
+	}
+	return n
+}
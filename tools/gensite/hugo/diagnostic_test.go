@@ -0,0 +1,43 @@
+package hugo
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseDiagnostic(t *testing.T) {
+	for line, want := range map[string]string{
+		`Start building sites …`: `<nil>`,
+		`WARN  2023/10/10 10:10:10 found no layout file for "html" for kind "taxonomy"`:                                                                    `warning: found no layout file for "html" for kind "taxonomy"`,
+		`WARN  Deprecated: .Site.Author was deprecated`:                                                                                                    `warning: Deprecated: .Site.Author was deprecated`,
+		`ERROR 2023/10/10 10:10:10 "content/bbc/_index.html:3:1": failed to unmarshal YAML`:                                                                `content/bbc/_index.html:3: error: "content/bbc/_index.html:3:1": failed to unmarshal YAML`,
+		`Error: error building site: "content/bbc/_index.html:7:1": failed to render shortcode "book/link": "layouts/shortcodes/book/link.html:2:10": bad`: `layouts/shortcodes/book/link.html:2: error: error building site: "content/bbc/_index.html:7:1": failed to render shortcode "book/link": "layouts/shortcodes/book/link.html:2:10": bad`,
+	} {
+		if got := fmt.Sprint(parseDiagnostic(line)); got != want {
+			t.Errorf("%q\ngot  %s\nwant %s", line, got, want)
+		}
+	}
+}
+
+func TestDiagnostics_Write(t *testing.T) {
+	d := &diagnostics{dir: "/site"}
+	for _, s := range []string{"Start building sites …\nERROR /site/layouts/", "index.html:4:2: bad\r\n", "WARN /tmp/x.html:1: odd"} {
+		if _, err := d.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = d.Close()
+
+	var got []string
+	for _, e := range d.list {
+		got = append(got, e.String())
+	}
+	want := "layouts/index.html:4: error: /site/layouts/index.html:4:2: bad\n/tmp/x.html:1: warning: /tmp/x.html:1: odd"
+	if strings.Join(got, "\n") != want {
+		t.Errorf("got\n%s", strings.Join(got, "\n"))
+	}
+	if len(d.tail) != 3 || d.count(LevelError) != 1 || d.count(LevelWarning) != 1 {
+		t.Errorf("got tail %q", d.tail)
+	}
+}
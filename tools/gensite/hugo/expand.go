@@ -0,0 +1,87 @@
+package hugo
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// ExpandData is what a template is executed against by Book.Expand
+type ExpandData struct {
+	*Book              // The book, e.g. {{.Title}}, {{.SubTitle}} or {{.Vars.edition}}
+	Config interface{} // Configuration of whatever the text is for, e.g. the pdf section of config.yaml
+}
+
+// Placeholders supported before Expand used templates, e.g. ${title}
+var legacyPlaceholder = regexp.MustCompile(`\$\{(modified|title|author|copyright)}`)
+
+// Expand renders text, e.g. the header & footer of a pdf, as a text/template against the book & config, see ExpandData.
+//
+// As well as the standard functions, these are available:
+//
+//	{{title}}             the book's title, or a placeholder for the browser's title if it has none
+//	{{author}}            the book's author
+//	{{copyright}}         the book's copyright
+//	{{modified}}          when the book's content was last modified, in RFC1123 format
+//	{{date "2 Jan 2006"}} when the book's content was last modified, in a time.Format layout
+//	{{date "2006" .Some}} a time.Time in a time.Format layout
+//	{{pageNumber}}        a placeholder for the page number when printing
+//	{{totalPages}}        a placeholder for the number of pages when printing
+//
+// e.g. {{title}}{{with .SubTitle}} - {{.}}{{end}}, page {{pageNumber}} of {{totalPages}}
+//
+// The ${modified}, ${title}, ${author} & ${copyright} placeholders from earlier versions are still supported.
+func (b *Book) Expand(s string, config interface{}) (string, error) {
+	s = legacyPlaceholder.ReplaceAllString(s, "{{$1}}")
+
+	t, err := template.New(b.ID).
+		Funcs(b.templateFuncs()).
+		Parse(s)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	if err = t.Execute(&sb, &ExpandData{Book: b, Config: config}); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+func (b *Book) templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"title": func() string {
+			if b.Title == "" {
+				return "<span class='title'></span>"
+			}
+			return b.Title
+		},
+		"author": func() string {
+			return b.Author
+		},
+		"copyright": func() string {
+			return b.Copyright
+		},
+		"modified": func() string {
+			return b.Modified().Format(time.RFC1123)
+		},
+		"date": func(layout string, t ...time.Time) (string, error) {
+			switch len(t) {
+			case 0:
+				return b.Modified().Format(layout), nil
+			case 1:
+				return t[0].Format(layout), nil
+			default:
+				return "", fmt.Errorf("date takes a layout & an optional time")
+			}
+		},
+		"pageNumber": func() string {
+			return "<span class='pageNumber'></span>"
+		},
+		"totalPages": func() string {
+			return "<span class='totalPages'></span>"
+		},
+	}
+}
@@ -0,0 +1,54 @@
+package hugo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBook_Expand(t *testing.T) {
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "_index.html")
+	if err := os.WriteFile(fileName, []byte("<p>Content</p>\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	modified := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	if err := os.Chtimes(fileName, modified, modified); err != nil {
+		t.Fatal(err)
+	}
+
+	b := &Book{
+		BookCopyright: BookCopyright{Title: "BBC Micro", Author: "Peter Mount", Copyright: "CC BY-SA"},
+		Vars:          map[string]interface{}{"edition": 2, "draft": true},
+	}
+	b.init("bbc", dir)
+
+	for s, want := range map[string]string{
+		// The title handler used to return the whole string
+		"<div>${title}</div>":                        "<div>BBC Micro</div>",
+		"${author} ${copyright}":                     "Peter Mount CC BY-SA",
+		"${modified}":                                modified.Local().Format(time.RFC1123),
+		"{{title}}{{with .SubTitle}} - {{.}}{{end}}": "BBC Micro",
+		`{{date "2 Jan 2006"}}`:                      modified.Local().Format("2 Jan 2006"),
+		"Edition {{.Vars.edition}}{{if .Vars.draft}} (draft){{end}}": "Edition 2 (draft)",
+		"{{.Config.width}}":             "8.3",
+		"{{pageNumber}}/{{totalPages}}": "<span class='pageNumber'></span>/<span class='totalPages'></span>",
+	} {
+		got, err := b.Expand(s, map[string]interface{}{"width": 8.3})
+		if err != nil {
+			t.Errorf("%q: %v", s, err)
+		} else if got != want {
+			t.Errorf("%q got %q want %q", s, got, want)
+		}
+	}
+
+	if _, err := b.Expand("{{.Unknown}}", nil); err == nil {
+		t.Error("expected error for unknown field")
+	}
+
+	b.Title = ""
+	if got, _ := b.Expand("${title}", nil); got != "<span class='title'></span>" {
+		t.Errorf("got %q without a title", got)
+	}
+}
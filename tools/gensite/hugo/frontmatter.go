@@ -1,25 +1,101 @@
 package hugo
 
 import (
-	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/BurntSushi/toml"
 	"github.com/peter-mount/documentation/tools/gensite/util"
 	"github.com/peter-mount/go-kernel/v2/util/walk"
 	"gopkg.in/yaml.v2"
 	"io"
 	"os"
 	"strings"
+	"time"
 )
 
 type FrontMatter struct {
-	Type       string                 `yaml:"type"`       // Template for this page
-	Title      string                 `yaml:"title"`      // Title of page
-	LinkTitle  string                 `yaml:"linkTitle"`  // Link title to this page
-	Weight     int                    `yaml:"weight"`     // Weight, 0=none. Use -1 if you specifically need 0
-	Categories []string               `yaml:"categories"` // Page categories
-	Tags       []string               `yaml:"tags"`       // Page tags
-	Book       *Book                  `yaml:"book"`       // Book definition
-	Other      map[string]interface{} `yaml:",inline"`    // All other data in raw format
+	Type        string                 `yaml:"type"`        // Template for this page
+	Title       string                 `yaml:"title"`       // Title of page
+	LinkTitle   string                 `yaml:"linkTitle"`   // Link title to this page
+	Weight      int                    `yaml:"weight"`      // Weight, 0=none. Use -1 if you specifically need 0
+	Categories  []string               `yaml:"categories"`  // Page categories
+	Tags        []string               `yaml:"tags"`        // Page tags
+	Book        *Book                  `yaml:"book"`        // Book definition
+	Draft       bool                   `yaml:"draft"`       // true if the page is a draft
+	Date        Date                   `yaml:"date"`        // Date of the page
+	PublishDate Date                   `yaml:"publishDate"` // Date the page is published, defaults to Date
+	ExpiryDate  Date                   `yaml:"expiryDate"`  // Date the page expires, if it does
+	Other       map[string]interface{} `yaml:",inline"`     // All other data in raw format
+}
+
+// Date is a date in front matter. Like hugo this can be a timestamp or a string containing a date, with or without
+// a time.
+type Date struct {
+	time.Time
+}
+
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+func (d *Date) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var t time.Time
+	if err := unmarshal(&t); err == nil {
+		d.Time = t
+		return nil
+	}
+
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	if s == "" {
+		return nil
+	}
+
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			d.Time = t
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid date %q", s)
+}
+
+// Published returns when the page is published, the zero time if it has no date
+func (fm *FrontMatter) Published() time.Time {
+	if !fm.PublishDate.IsZero() {
+		return fm.PublishDate.Time
+	}
+	return fm.Date.Time
+}
+
+// BuildOptions are which pages hugo builds, the same as its --buildDrafts, --buildFuture & --buildExpired flags
+type BuildOptions struct {
+	Drafts  bool // Build drafts
+	Future  bool // Build pages published in the future
+	Expired bool // Build pages which have expired
+}
+
+// Builds returns true if hugo would build a page at a time with these options
+func (o BuildOptions) Builds(fm *FrontMatter, now time.Time) bool {
+	switch {
+	case fm.Draft && !o.Drafts:
+		return false
+	case fm.Published().After(now) && !o.Future:
+		return false
+	case !fm.ExpiryDate.IsZero() && !fm.ExpiryDate.After(now) && !o.Expired:
+		return false
+	default:
+		return true
+	}
 }
 
 type FrontMatterAction func(context.Context, *FrontMatter) error
@@ -54,6 +130,8 @@ func (a FrontMatterAction) Then(b FrontMatterAction) FrontMatterAction {
 func (a FrontMatterAction) OtherExists(key string, f FrontMatterAction) FrontMatterAction {
 	return a.Then(func(ctx context.Context, fm *FrontMatter) error {
 		if val, exists := fm.Other[key]; exists {
+			// Record the page as being used by whatever is being generated
+			util.GetProducer(ctx).AddSource(FilePath(ctx))
 			return f.Do(context.WithValue(ctx, "other", val), fm)
 		}
 		return nil
@@ -92,14 +170,39 @@ func (a FrontMatterAction) Do(ctx context.Context, fm *FrontMatter) error {
 
 func (a FrontMatterAction) Walk(ctx context.Context) walk.PathWalker {
 	return func(path string, fileInfo os.FileInfo) error {
-		fm := &FrontMatter{}
-		if err := fm.LoadFrontMatter(path); err != nil {
+		// Walkers may pass directories, which have no front matter
+		if fileInfo != nil && fileInfo.IsDir() {
+			return nil
+		}
+
+		buf, err := os.ReadFile(path)
+		if err != nil {
 			return err
 		}
 
-		ctx = context.WithValue(ctx, "fileInfo", fileInfo)
-		return a.Do(ctx, fm)
+		fm := &FrontMatter{}
+		body, err := fm.parse(buf)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		return a.Do(pageContext(ctx, path, fileInfo, body), fm)
+	}
+}
+
+// pageContext returns the context used when processing a page
+func pageContext(ctx context.Context, path string, fileInfo os.FileInfo, body *Body) context.Context {
+	ctx = context.WithValue(ctx, "fileInfo", fileInfo)
+	ctx = context.WithValue(ctx, "filePath", path)
+	return context.WithValue(ctx, "pageBody", body)
+}
+
+// FilePath returns the path of the page being processed
+func FilePath(ctx context.Context) string {
+	if p, ok := ctx.Value("filePath").(string); ok {
+		return p
 	}
+	return ""
 }
 
 func FileInfo(ctx context.Context) os.FileInfo {
@@ -110,41 +213,158 @@ func FileInfo(ctx context.Context) os.FileInfo {
 	return nil
 }
 
+// PageBody returns the Body of the page being processed
+func PageBody(ctx context.Context) *Body {
+	if b, ok := ctx.Value("pageBody").(*Body); ok {
+		return b
+	}
+	return nil
+}
+
+// LoadFrontMatter loads the front matter of a page, see ReadFrontMatter
 func (fm *FrontMatter) LoadFrontMatter(fileName string) error {
-	f, err := os.Open(fileName)
+	buf, err := os.ReadFile(fileName)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
 
-	return fm.ReadFrontMatter(f)
+	if _, err = fm.parse(buf); err != nil {
+		return fmt.Errorf("%s: %w", fileName, err)
+	}
+	return nil
 }
 
+// ReadFrontMatter reads the front matter at the start of a page.
+// Like hugo this can be YAML between "---" lines, TOML between "+++" lines, or a JSON object.
+// A page without front matter is valid, but one whose front matter is not terminated is not.
 func (fm *FrontMatter) ReadFrontMatter(r io.Reader) error {
-	scanner := bufio.NewScanner(r)
-	for scanner.Scan() {
-		l := scanner.Text()
-		if l == "---" {
-			return fm.readFrontMatter(scanner)
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	_, err = fm.parse(buf)
+	return err
+}
+
+// parse the front matter at the start of a page, returning the Body which follows it
+func (fm *FrontMatter) parse(buf []byte) (*Body, error) {
+	r, err := SplitFrontMatter(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	body := newBody(buf, r.body)
+	switch r.Format {
+	case "":
+		// No front matter
+		return body, nil
+
+	case FormatYAML:
+		return body, yaml.Unmarshal(r.Data, fm)
+
+	default:
+		m, err := r.Map()
+		if err != nil {
+			return nil, err
 		}
+		return body, fm.decode(m)
 	}
+}
 
-	// No frontmatter
-	return nil
+const (
+	FormatYAML = "yaml" // YAML front matter between "---" lines
+	FormatTOML = "toml" // TOML front matter between "+++" lines
+	FormatJSON = "json" // JSON object
+)
+
+// RawFrontMatter is the front matter at the start of a page before it has been decoded
+type RawFrontMatter struct {
+	Format string // FormatYAML, FormatTOML or FormatJSON, "" if the page has no front matter
+	Line   int    // Line of the page the front matter starts on, i.e. the opening delimiter or brace
+	Data   []byte // The front matter excluding any delimiters
+	body   int    // Offset of the Body following the front matter
 }
 
-func (fm *FrontMatter) readFrontMatter(s *bufio.Scanner) error {
-	var a []string
-	for s.Scan() {
-		l := s.Text()
-		if l == "---" {
-			b := []byte(strings.Join(a, "\n"))
-			return yaml.Unmarshal(b, fm)
+// SplitFrontMatter returns the front matter at the start of a page, see ReadFrontMatter.
+// If it is not terminated an error is returned along with where it starts.
+func SplitFrontMatter(buf []byte) (*RawFrontMatter, error) {
+	content := bytes.TrimLeft(bytes.TrimPrefix(buf, []byte("\uFEFF")), " \t\r\n")
+	start := len(buf) - len(content)
+	r := &RawFrontMatter{Line: bytes.Count(buf[:start], []byte("\n")) + 1}
+
+	for _, f := range []struct{ format, delim string }{{FormatYAML, "---"}, {FormatTOML, "+++"}} {
+		if frontMatterDelimiter(content, f.delim) {
+			b, rest, err := frontMatterBlock(content, f.delim)
+			if err != nil {
+				return r, err
+			}
+			r.Format, r.Data, r.body = f.format, b, len(buf)-len(rest)
+			return r, nil
 		}
+	}
 
-		a = append(a, l)
+	if len(content) > 0 && content[0] == '{' {
+		var b json.RawMessage
+		dec := json.NewDecoder(bytes.NewReader(content))
+		if err := dec.Decode(&b); err != nil {
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				return r, errors.New("unterminated JSON front matter")
+			}
+			return r, err
+		}
+
+		// The body starts on the line after the closing brace
+		offset := start + int(dec.InputOffset())
+		offset += len(buf[offset:]) - len(bytes.TrimPrefix(bytes.TrimPrefix(buf[offset:], []byte("\r")), []byte("\n")))
+		r.Format, r.Data, r.body = FormatJSON, b, offset
 	}
 
-	// no --- terminator so ignore the page
-	return nil
+	return r, nil
+}
+
+// Map decodes the front matter into a map, which is empty if the page has no front matter
+func (r *RawFrontMatter) Map() (map[string]interface{}, error) {
+	m := make(map[string]interface{})
+	var err error
+	switch r.Format {
+	case FormatYAML:
+		err = yaml.Unmarshal(r.Data, &m)
+	case FormatTOML:
+		_, err = toml.Decode(string(r.Data), &m)
+	case FormatJSON:
+		err = json.Unmarshal(r.Data, &m)
+	}
+	return m, err
+}
+
+// frontMatterDelimiter returns true if the first line of buf is a front matter delimiter
+func frontMatterDelimiter(buf []byte, delim string) bool {
+	l, _, _ := bytes.Cut(buf, []byte("\n"))
+	return strings.TrimRight(string(l), " \t\r") == delim
+}
+
+// frontMatterBlock returns the front matter between the delimiter on the first line of buf & the next one,
+// and the rest of buf following that delimiter
+func frontMatterBlock(buf []byte, delim string) ([]byte, []byte, error) {
+	_, block, _ := bytes.Cut(buf, []byte("\n"))
+	rest := block
+	for len(rest) > 0 {
+		l, next, _ := bytes.Cut(rest, []byte("\n"))
+		if strings.TrimRight(string(l), " \t\r") == delim {
+			return block[:len(block)-len(rest)], next, nil
+		}
+		rest = next
+	}
+	return nil, nil, fmt.Errorf("unterminated front matter, no closing %q", delim)
+}
+
+// decode front matter which is not YAML. This is done via YAML so that values have the same types as front matter
+// in YAML, e.g. maps are map[interface{}]interface{} & integers are int
+func (fm *FrontMatter) decode(m map[string]interface{}) error {
+	b, err := yaml.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(b, fm)
 }
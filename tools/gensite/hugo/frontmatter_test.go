@@ -0,0 +1,145 @@
+package hugo
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFrontMatter_ReadFrontMatter(t *testing.T) {
+	for name, page := range map[string]string{
+		"yaml": `---
+title: "Rotate"
+weight: 10
+codes:
+  - op: ROL
+    cycles:
+      value: 6
+      notes: [ 1 ]
+---
+<p>Content</p>
+---
+`,
+		"toml": `
++++
+title = "Rotate"
+weight = 10
+
+[[codes]]
+op = "ROL"
+
+[codes.cycles]
+value = 6
+notes = [ 1 ]
++++
+<p>Content</p>
+`,
+		"json": `{
+  "title": "Rotate",
+  "weight": 10,
+  "codes": [ { "op": "ROL", "cycles": { "value": 6, "notes": [ 1 ] } } ]
+}
+<p>Content</p>
+`,
+	} {
+		fm := &FrontMatter{}
+		if err := fm.ReadFrontMatter(strings.NewReader(page)); err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+
+		if fm.Title != "Rotate" || fm.Weight != 10 {
+			t.Errorf("%s: got title %q weight %d", name, fm.Title, fm.Weight)
+		}
+
+		// Generators expect the same types whatever the format
+		codes, ok := fm.Other["codes"].([]interface{})
+		if !ok || len(codes) != 1 {
+			t.Errorf("%s: got codes %T %v", name, fm.Other["codes"], fm.Other["codes"])
+			continue
+		}
+		code, ok := codes[0].(map[interface{}]interface{})
+		if !ok {
+			t.Errorf("%s: got code %T", name, codes[0])
+			continue
+		}
+		if got := fmt.Sprintf("%s %#v", code["op"], code["cycles"]); got != `ROL map[interface {}]interface {}{"notes":[]interface {}{1}, "value":6}` {
+			t.Errorf("%s: got %s", name, got)
+		}
+	}
+}
+
+func TestFrontMatter_ReadFrontMatter_none(t *testing.T) {
+	for _, page := range []string{"", "<p>Content</p>\n---\ntitle: \"Not front matter\"\n---\n"} {
+		fm := &FrontMatter{}
+		if err := fm.ReadFrontMatter(strings.NewReader(page)); err != nil || fm.Title != "" {
+			t.Errorf("%q got %q %v", page, fm.Title, err)
+		}
+	}
+}
+
+func TestFrontMatter_ReadFrontMatter_unterminated(t *testing.T) {
+	for _, page := range []string{
+		"---\ntitle: \"Rotate\"\n",
+		"+++\ntitle = \"Rotate\"\n---\n",
+		"{\n  \"title\": \"Rotate\"\n",
+	} {
+		fm := &FrontMatter{}
+		if err := fm.ReadFrontMatter(strings.NewReader(page)); err == nil || !strings.Contains(err.Error(), "unterminated") {
+			t.Errorf("%q got %v", page, err)
+		}
+	}
+}
+
+func TestFrontMatter_dates(t *testing.T) {
+	for name, page := range map[string]string{
+		"yaml":   "---\ndate: 2021-03-04\npublishDate: 2021-03-05T10:00:00Z\nexpiryDate: \"2021-03-06 12:00:00\"\n---\n",
+		"toml":   "+++\ndate = 2021-03-04\npublishDate = 2021-03-05T10:00:00Z\nexpiryDate = \"2021-03-06 12:00:00\"\n+++\n",
+		"json":   "{\"date\": \"2021-03-04\", \"publishDate\": \"2021-03-05T10:00:00Z\", \"expiryDate\": \"2021-03-06 12:00:00\"}\n",
+		"string": "---\ndate: \"2021-03-04\"\npublishDate: \"2021-03-05T10:00:00Z\"\nexpiryDate: 2021-03-06 12:00:00\n---\n",
+	} {
+		fm := &FrontMatter{}
+		if err := fm.ReadFrontMatter(strings.NewReader(page)); err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+
+		got := fmt.Sprintf("%s %s %s",
+			fm.Date.Format("2006-01-02"),
+			fm.Published().UTC().Format(time.RFC3339),
+			fm.ExpiryDate.Format("2006-01-02 15:04"))
+		if got != "2021-03-04 2021-03-05T10:00:00Z 2021-03-06 12:00" {
+			t.Errorf("%s: got %s", name, got)
+		}
+	}
+
+	fm := &FrontMatter{}
+	if err := fm.ReadFrontMatter(strings.NewReader("---\ndate: \"next tuesday\"\n---\n")); err == nil {
+		t.Error("expected invalid date")
+	}
+}
+
+func TestBuildOptions_Builds(t *testing.T) {
+	now := time.Date(2021, 3, 4, 12, 0, 0, 0, time.UTC)
+	past := Date{now.Add(-time.Hour)}
+	future := Date{now.Add(time.Hour)}
+
+	for name, test := range map[string]struct {
+		fm   FrontMatter
+		want [4]bool // Default, Drafts, Future, Expired
+	}{
+		"page":           {FrontMatter{}, [4]bool{true, true, true, true}},
+		"dated":          {FrontMatter{Date: past, ExpiryDate: future}, [4]bool{true, true, true, true}},
+		"draft":          {FrontMatter{Draft: true}, [4]bool{false, true, false, false}},
+		"future date":    {FrontMatter{Date: future}, [4]bool{false, false, true, false}},
+		"future publish": {FrontMatter{Date: past, PublishDate: future}, [4]bool{false, false, true, false}},
+		"expired":        {FrontMatter{ExpiryDate: past}, [4]bool{false, false, false, true}},
+	} {
+		for i, o := range []BuildOptions{{}, {Drafts: true}, {Future: true}, {Expired: true}} {
+			if got := o.Builds(&test.fm, now); got != test.want[i] {
+				t.Errorf("%s %+v got %v", name, o, got)
+			}
+		}
+	}
+}
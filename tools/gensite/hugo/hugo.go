@@ -2,26 +2,70 @@ package hugo
 
 import (
 	"context"
+	"fmt"
 	"github.com/peter-mount/documentation/tools/gensite"
+	"github.com/peter-mount/documentation/tools/gensite/util"
 	"github.com/peter-mount/go-kernel/v2/log"
 	"github.com/peter-mount/go-kernel/v2/util/strings"
 	"github.com/peter-mount/go-kernel/v2/util/task"
 	"os"
 	"os/exec"
+	strings2 "strings"
 )
 
-// Hugo runs hugo
+// Hugo runs hugo.
+//
+// Hugo's output is captured & any errors or warnings in it are reported once it has finished, see Diagnostic.
+// With -v the output is also shown as hugo runs.
 type Hugo struct {
-	server  *bool      `kernel:"flag,s,Run hugo in server mode"`                       // true to run Hugo in server mode
-	cleanup *bool      `kernel:"flag,clean,Cleanup public directories before running"` // true to clean out public directory before running
-	draft   *bool      `kernel:"flag,buildDrafts,Build draft pages"`                   // true to build drafts, same as --buildDrafts for hugo
-	expired *bool      `kernel:"flag,buildExpired,Build expired pages"`                // true to build expired content, same as --buildExpired for hugo
-	future  *bool      `kernel:"flag,buildFuture,Build future pages"`                  // true to build future content, same as --buildFuture for hugo
-	hugoCmd *string    `kernel:"flag,hugo,Hugo binary to use,hugo"`
-	worker  task.Queue `kernel:"worker"` // Worker queue
+	server   *bool          `kernel:"flag,s,Run hugo in server mode"`                       // true to run Hugo in server mode
+	cleanup  *bool          `kernel:"flag,clean,Cleanup public directories before running"` // true to clean out public directory before running
+	draft    *bool          `kernel:"flag,buildDrafts,Build draft pages"`                   // true to build drafts, same as --buildDrafts for hugo
+	expired  *bool          `kernel:"flag,buildExpired,Build expired pages"`                // true to build expired content, same as --buildExpired for hugo
+	future   *bool          `kernel:"flag,buildFuture,Build future pages"`                  // true to build future content, same as --buildFuture for hugo
+	hugoCmd  *string        `kernel:"flag,hugo,Hugo binary to use,hugo"`
+	config   *Config        `kernel:"config,hugo"` // Additional configuration
+	dryRun   *util.DryRun   `kernel:"inject"`      // DryRun
+	validate *util.Validate `kernel:"inject"`      // Validate
+	worker   task.Queue     `kernel:"worker"`      // Worker queue
+}
+
+// Config is the hugo section of config.yaml, e.g.
+//
+//	hugo:
+//	  args: [ "--baseURL", "https://example.com/", "--environment", "staging" ]
+//	  env:
+//	    HUGO_PARAMS_ANALYTICS: "false"
+//
+// Any HUGO_ variables set in the environment gensite is run in are passed to hugo & override those in Env.
+type Config struct {
+	Args []string          `yaml:"args"` // Additional arguments, e.g. --baseURL, --environment or --destination
+	Env  map[string]string `yaml:"env"`  // HUGO_ environment variables to set
+}
+
+// environ returns the environment to run hugo with
+func (c *Config) environ() []string {
+	env := os.Environ()
+	for k, v := range c.Env {
+		if _, exists := os.LookupEnv(k); !exists {
+			env = append(env, k+"="+v)
+		}
+	}
+	return env
 }
 
 func (h *Hugo) Start() error {
+	for k := range h.config.Env {
+		if !strings2.HasPrefix(k, "HUGO_") {
+			return fmt.Errorf("hugo env %s is not a HUGO_ variable", k)
+		}
+	}
+
+	// Nothing to clean up or render in dry-run or validate mode as nothing has been written
+	if h.dryRun.Enabled() || h.validate.Enabled() {
+		return nil
+	}
+
 	if *h.cleanup {
 		h.worker.AddPriorityTask(tools.PriorityImmediate, h.cleanupTask)
 	}
@@ -40,9 +84,19 @@ func (h *Hugo) cleanupTask(_ context.Context) error {
 		"static/static/book",
 		"static/static/chipref",
 		"static/static/gen",
+		".gensite-cache",
 	).ForEach(os.RemoveAll)
 }
 
+// BuildOptions returns which pages hugo will build
+func (h *Hugo) BuildOptions() BuildOptions {
+	return BuildOptions{
+		Drafts:  *h.draft,
+		Future:  *h.future,
+		Expired: *h.expired,
+	}
+}
+
 func appendArg(a []string, flag bool, s ...string) []string {
 	if flag {
 		return append(a, s...)
@@ -59,17 +113,47 @@ func (h *Hugo) run(_ context.Context) error {
 	args = appendArg(args, *h.expired, "--buildExpired")
 	args = appendArg(args, *h.future, "--buildFuture")
 
-	if log.IsVerbose() {
-		//args = append(args, "-v")
-	} else {
+	if !log.IsVerbose() {
 		args = append(args, "--quiet")
 	}
 
+	args = append(args, h.config.Args...)
+
+	dir, _ := os.Getwd()
+	diags := &diagnostics{dir: dir}
+	// Show the output as it happens when verbose or the server is running as that runs until stopped
+	if log.IsVerbose() || *h.server {
+		diags.out = os.Stdout
+	}
+
 	cmd := exec.Command(*h.hugoCmd, args...)
-	if log.IsVerbose() {
-		cmd.Stdout = os.Stdout
+	cmd.Env = h.config.environ()
+	cmd.Stdout = diags
+	cmd.Stderr = diags
+
+	err := cmd.Run()
+	_ = diags.Close()
+
+	if diags.out == nil {
+		for _, d := range diags.list {
+			_, _ = fmt.Fprintln(os.Stderr, d)
+		}
 	}
-	cmd.Stderr = os.Stderr
 
-	return cmd.Run()
+	errors, warnings := diags.count(LevelError), diags.count(LevelWarning)
+	if errors > 0 || warnings > 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "hugo reported %d errors & %d warnings\n", errors, warnings)
+	}
+
+	if err != nil {
+		// Show what hugo said last if there's nothing else to go on
+		if errors == 0 && diags.out == nil {
+			for _, l := range diags.tail {
+				_, _ = fmt.Fprintln(os.Stderr, l)
+			}
+		}
+		return fmt.Errorf("hugo failed: %w", err)
+	}
+
+	return nil
 }
@@ -0,0 +1,230 @@
+package hugo
+
+import (
+	"errors"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	SiteConfig      = "config.toml" // Hugo's configuration
+	I18nDir         = "i18n"        // Hugo's translation tables
+	DefaultLanguage = "en"          // Default language if the site does not declare one
+)
+
+// Languages are the languages of the site, as declared in SiteConfig, e.g.
+//
+//	defaultContentLanguage = "en"
+//	[languages.en]
+//	languageName = "English"
+//	[languages.fr]
+//	languageName = "Français"
+//	contentDir = "content/fr"
+//
+// Without a contentDir a page is translated by adding the language to its name, e.g. _index.fr.html.
+//
+// Text generated into pages, e.g. titles, is translated using the tables in I18nDir, see Language.I18n.
+type Languages struct {
+	languages []*Language
+}
+
+// Language is a language of the site
+type Language struct {
+	Code       string            // Language code, e.g. "fr"
+	Name       string            // Name of the language
+	Weight     int               // Weight of the language
+	ContentDir string            // Content directory, "" if pages are translated by name
+	Default    bool              // true if this is the default language
+	i18n       map[string]string // Translations by id
+}
+
+// siteConfig is the part of SiteConfig we need
+type siteConfig struct {
+	DefaultContentLanguage string `toml:"defaultContentLanguage"`
+	Languages              map[string]struct {
+		LanguageName string `toml:"languageName"`
+		Weight       int    `toml:"weight"`
+		ContentDir   string `toml:"contentDir"`
+	} `toml:"languages"`
+}
+
+func (l *Languages) Start() error {
+	config := &siteConfig{}
+	if _, err := toml.DecodeFile(SiteConfig, config); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("%s: %w", SiteConfig, err)
+	}
+
+	languages, err := newLanguages(config)
+	if err != nil {
+		return fmt.Errorf("%s: %w", SiteConfig, err)
+	}
+
+	for _, lang := range languages {
+		if lang.i18n, err = loadI18n(I18nDir, lang.Code); err != nil {
+			return err
+		}
+	}
+
+	l.languages = languages
+	return nil
+}
+
+// newLanguages returns the languages declared in the site config, the default first then by weight
+func newLanguages(config *siteConfig) ([]*Language, error) {
+	def := config.DefaultContentLanguage
+	if def == "" {
+		def = DefaultLanguage
+	}
+
+	languages := []*Language{{Code: def, Default: true}}
+	for code, c := range config.Languages {
+		lang := &Language{Code: code, Name: c.LanguageName, Weight: c.Weight}
+		if c.ContentDir != "" {
+			lang.ContentDir = path.Clean(c.ContentDir)
+		}
+
+		if code != def {
+			languages = append(languages, lang)
+		} else if lang.ContentDir != "" && lang.ContentDir != "content" {
+			return nil, fmt.Errorf("default language %s must use the content directory", code)
+		} else {
+			lang.Default = true
+			lang.ContentDir = ""
+			languages[0] = lang
+		}
+	}
+
+	sort.SliceStable(languages[1:], func(i, j int) bool {
+		a, b := languages[i+1], languages[j+1]
+		if a.Weight != b.Weight {
+			return a.Weight < b.Weight
+		}
+		return a.Code < b.Code
+	})
+
+	return languages, nil
+}
+
+// Default returns the default language
+func (l *Languages) Default() *Language {
+	return l.languages[0]
+}
+
+// Translations returns the languages other than the default
+func (l *Languages) Translations() []*Language {
+	return l.languages[1:]
+}
+
+// Get returns a Language by its code, nil if the site does not have it
+func (l *Languages) Get(code string) *Language {
+	for _, lang := range l.languages {
+		if lang.Code == code {
+			return lang
+		}
+	}
+	return nil
+}
+
+// split returns a page's name without its language, e.g. "a/_index.fr.html" returns "a/_index.html" & the fr Language.
+// The Language is nil if the name has none.
+func (l *Languages) split(name string) (string, *Language) {
+	if l == nil {
+		return name, nil
+	}
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	if lang := l.Get(strings.TrimPrefix(path.Ext(base), ".")); lang != nil {
+		return strings.TrimSuffix(base, "."+lang.Code) + ext, lang
+	}
+	return name, nil
+}
+
+// hasContent returns true if a directory has content in a language, ignoring generated reference pages.
+// With a content directory that is any content, otherwise any page with the language in its name.
+func (l *Languages) hasContent(dir string, lang *Language) bool {
+	found := errors.New("found")
+	err := filepath.Walk(dir, func(fileName string, info os.FileInfo, err error) error {
+		switch {
+		case err != nil:
+			return err
+		case info.IsDir():
+			if info.Name() == "reference" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if _, l := l.split(fileName); lang.ContentDir != "" || l == lang {
+			return found
+		}
+		return nil
+	})
+	return err == found
+}
+
+// I18n returns the translation of id in this language, other if there is none
+func (l *Language) I18n(id, other string) string {
+	if l != nil {
+		if s, exists := l.i18n[id]; exists && s != "" {
+			return s
+		}
+	}
+	return other
+}
+
+// PageName returns the name of a page in this language, e.g. "_index.html" is "_index.fr.html" when translating by name
+func (l *Language) PageName(name string) string {
+	if l == nil || l.Default || l.ContentDir != "" {
+		return name
+	}
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + l.Code + ext
+}
+
+// loadI18n loads the translation table for a language in the same formats as hugo, so either
+//
+//	[reference_osbyte_title]
+//	other = "Appels OSBYTE"
+//
+// or reference_osbyte_title = "Appels OSBYTE" in toml, or the equivalent yaml.
+func loadI18n(dir, code string) (map[string]string, error) {
+	m := make(map[string]string)
+	for _, ext := range []string{".toml", ".yaml", ".yml"} {
+		fileName := path.Join(dir, code+ext)
+		buf, err := os.ReadFile(fileName)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		table := make(map[string]interface{})
+		if ext == ".toml" {
+			err = toml.Unmarshal(buf, &table)
+		} else {
+			err = yaml.Unmarshal(buf, &table)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fileName, err)
+		}
+
+		for id, v := range table {
+			switch v := v.(type) {
+			case string:
+				m[id] = v
+			case map[string]interface{}:
+				m[id], _ = v["other"].(string)
+			case map[interface{}]interface{}:
+				m[id], _ = v["other"].(string)
+			}
+		}
+	}
+	return m, nil
+}
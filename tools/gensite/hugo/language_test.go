@@ -0,0 +1,98 @@
+package hugo
+
+import (
+	"github.com/BurntSushi/toml"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testLanguages(t *testing.T, config string) *Languages {
+	c := &siteConfig{}
+	if _, err := toml.Decode(config, c); err != nil {
+		t.Fatal(err)
+	}
+	languages, err := newLanguages(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Languages{languages: languages}
+}
+
+func TestLanguages(t *testing.T) {
+	l := testLanguages(t, `
+defaultContentLanguage = "en"
+[languages.en]
+languageName = "English"
+[languages.fr]
+languageName = "Français"
+weight = 2
+[languages.de]
+languageName = "Deutsch"
+weight = 1
+contentDir = "content/de/"
+`)
+
+	if d := l.Default(); d.Code != "en" || d.Name != "English" || !d.Default {
+		t.Errorf("got default %+v", d)
+	}
+	if tr := l.Translations(); len(tr) != 2 || tr[0].Code != "de" || tr[0].ContentDir != "content/de" || tr[1].Code != "fr" {
+		t.Errorf("got translations %+v", tr)
+	}
+
+	for name, want := range map[string]string{
+		"a/_index.fr.html": "a/_index.html fr",
+		"a/_index.en.html": "a/_index.html en",
+		"a/_index.es.html": "a/_index.es.html ",
+		"a/_index.html":    "a/_index.html ",
+		"a/6502.html":      "a/6502.html ",
+	} {
+		n, lang := l.split(name)
+		code := ""
+		if lang != nil {
+			code = lang.Code
+		}
+		if got := n + " " + code; got != want {
+			t.Errorf("%s got %q want %q", name, got, want)
+		}
+	}
+
+	for code, want := range map[string]string{"en": "_index.html", "fr": "_index.fr.html", "de": "_index.html"} {
+		if got := l.Get(code).PageName("_index.html"); got != want {
+			t.Errorf("%s got %q", code, got)
+		}
+	}
+
+	// Only the default language without any configuration
+	if l := testLanguages(t, ""); len(l.languages) != 1 || l.Default().Code != DefaultLanguage {
+		t.Errorf("got %+v", l.languages)
+	}
+}
+
+func TestLoadI18n(t *testing.T) {
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, "fr.toml"), []byte("reference_osword_title = \"Appels OSWord\"\n\n[reference_osbyte_title]\nother = \"Appels OSByte\"\n"), 0644)
+	_ = os.WriteFile(filepath.Join(dir, "fr.yaml"), []byte("reference_hexgrid_title:\n  other: Matrice\n"), 0644)
+
+	m, err := loadI18n(dir, "fr")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lang := &Language{Code: "fr", i18n: m}
+	for id, want := range map[string]string{
+		"reference_osbyte_title":  "Appels OSByte",
+		"reference_osword_title":  "Appels OSWord",
+		"reference_hexgrid_title": "Matrice",
+		"reference_other_title":   "Other",
+	} {
+		if got := lang.I18n(id, "Other"); got != want {
+			t.Errorf("%s got %q", id, got)
+		}
+	}
+
+	var none *Language
+	if none.I18n("reference_osbyte_title", "OSByte calls") != "OSByte calls" {
+		t.Error("nil language")
+	}
+}
@@ -59,26 +59,40 @@ func (l *LaTeX) generate(book *hugo.Book) error {
 	}
 
 	tex := filepath.Join(bookDir, book.ID+".tex")
-	//pdf := filepath.Join(bookDir, book.ID+".pdf")
+	pdf := filepath.Join(bookDir, book.ID+".pdf")
 
 	err = l.generateTeX(book, tex)
 	if err == nil {
 		err = l.generatePdf(tex)
 	}
+
+	// Move the pdf if the book has been configured to have it elsewhere
+	if err == nil && book.PDFFile() != pdf {
+		if err = os.MkdirAll(filepath.Dir(book.PDFFile()), 0777); err == nil {
+			err = os.Rename(pdf, book.PDFFile())
+		}
+	}
 	return err
 }
 
 func (l *LaTeX) generateTeX(book *hugo.Book, tex string) error {
 	log.Println("Generating LaTeX for", book.ID)
 
-	url := l.webserver.WebPath("%s/_print/index.html", strings.ToLower(book.WebPath()))
-
-	log.Printf("Retrieving %s", url)
-	p, err := parser.Parse(url)
+	p, err := l.parsePrint(book)
 	if err != nil {
 		return err
 	}
 
+	// Each volume follows as a part of the book
+	var volumes []*parser.Parser
+	for _, v := range book.VolumeBooks() {
+		vp, err := l.parsePrint(v)
+		if err != nil {
+			return err
+		}
+		volumes = append(volumes, vp)
+	}
+
 	log.Println("Creating", tex)
 	f, err := os.Create(tex)
 	if err != nil {
@@ -118,10 +132,28 @@ func (l *LaTeX) generateTeX(book *hugo.Book, tex string) error {
 		}
 	}
 
+	for i, v := range book.VolumeBooks() {
+		w.WriteString("\\part{%s}\n", v.Title)
+		for _, n := range volumes[i].GetElementByClass("td-main") {
+			err = l.parseNode(w, n)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	w.WriteString("\\end{document}")
 	return nil
 }
 
+// parsePrint retrieves & parses the print version of a book
+func (l *LaTeX) parsePrint(book *hugo.Book) (*parser.Parser, error) {
+	url := l.webserver.WebPath("%s/_print/index.html", strings.ToLower(book.WebPath()))
+
+	log.Printf("Retrieving %s", url)
+	return parser.Parse(url)
+}
+
 func (l *LaTeX) generatePdf(tex string) error {
 	tmpDir := filepath.Dir(tex)
 
@@ -3,6 +3,7 @@ package autodoc
 import (
 	"context"
 	"github.com/peter-mount/documentation/tools/gensite"
+	"github.com/peter-mount/documentation/tools/gensite/util"
 	"github.com/peter-mount/go-kernel/v2/util/task"
 	"io"
 	"os"
@@ -56,7 +57,7 @@ func InitBuilder(dir, file string, modified time.Time, asm, suffix, title, desc
 	fsName := file + "." + suffix
 	buildFileName := path.Join(dir, asm, fsName)
 
-	if fi, err := os.Stat(buildFileName); err != nil {
+	if fi, err := util.Stat(buildFileName); err != nil {
 		// If error is not-existing then fall through with writeNow is true
 		if !os.IsNotExist(err) {
 			return "", nil, err
@@ -67,19 +68,15 @@ func InitBuilder(dir, file string, modified time.Time, asm, suffix, title, desc
 		writeNow = modified.After(fi.ModTime())
 	}
 
-	if err := os.MkdirAll(path.Dir(buildFileName), 0755); err != nil {
-		return "", nil, err
-	}
-
 	// Add indices files later
 	task.GetQueue(ctx).
 		AddPriorityTask(tools.PriorityAutodoc, GenerateReferenceIndices(buildFileName, modified).
-			WithContext(ctx, ResourceManagerKey)).
+			WithContext(ctx, ResourceManagerKey, util.ProducerKey)).
 		AddPriorityTask(tools.PriorityAutodoc, GenerateFileIndexPage(dir, file, asm, buildFileName, modified).
-			WithContext(ctx, ResourceManagerKey))
+			WithContext(ctx, ResourceManagerKey, util.ProducerKey))
 
 	if writeNow {
-		f, err := os.Create(buildFileName)
+		f, err := util.Create(ctx, buildFileName, modified)
 		if err != nil {
 			return "", nil, err
 		}
@@ -87,22 +84,19 @@ func InitBuilder(dir, file string, modified time.Time, asm, suffix, title, desc
 		return buildFileName, f, nil
 	}
 
+	util.Keep(ctx, buildFileName)
 	return buildFileName, nil, nil
 }
 
-func CloseBuilder(err error, w io.WriteCloser, fileName string, modified time.Time, ctx context.Context) error {
-	if w != nil {
-		if err == nil {
-			err = w.Close()
-		}
-
-		if err == nil {
-			if modified.IsZero() {
-				modified = time.Now()
-			}
-
-			err = os.Chtimes(fileName, modified, modified)
-		}
+// CloseBuilder closes the file created by InitBuilder, which sets its modified time.
+// If the build failed the file is abandoned instead.
+func CloseBuilder(err error, w io.WriteCloser, _ string, _ time.Time, _ context.Context) error {
+	switch {
+	case w == nil:
+	case err == nil:
+		err = w.Close()
+	default:
+		_ = util.Abort(w)
 	}
 
 	return err
@@ -16,20 +16,23 @@ const (
 
 type CustomIndexFileGenerator func(fileName string, fileTime time.Time) error
 
-func GenerateCustomIndexFile(fileName string, fileTime time.Time, f CustomIndexFileGenerator) error {
-	if _, err := os.Stat(fileName); err != nil {
+func GenerateCustomIndexFile(ctx context.Context, fileName string, fileTime time.Time, f CustomIndexFileGenerator) error {
+	if _, err := util.Stat(fileName); err != nil {
 		if !os.IsNotExist(err) {
 			return err
 		}
 		return f(fileName, fileTime)
 	}
+	util.Keep(ctx, fileName)
 	return nil
 }
 
+// GenerateReferenceIndexFile queues the writing of a reference index page, if it does not already exist.
+// Any resources for its directory are included when the context has a ResourceManager.
 func GenerateReferenceIndexFile(ctx context.Context, fileName string, fileTime time.Time, title, desc string) error {
-	return task.Of(func(ctx context.Context) error {
+	t := task.Of(func(ctx context.Context) error {
 
-		return GenerateCustomIndexFile(fileName, fileTime, func(fileName string, fileTime time.Time) error {
+		return GenerateCustomIndexFile(ctx, fileName, fileTime, func(fileName string, fileTime time.Time) error {
 			fb := util.ReferenceFileBuilder(title, desc, "manual", 100, fileTime)
 
 			// Get any resources for this location
@@ -42,11 +45,16 @@ func GenerateReferenceIndexFile(ctx context.Context, fileName string, fileTime t
 			return fb.
 				WrapAsFrontMatter().
 				FileHandler().
-				WriteAlways(fileName, fileTime)
+				WriteAlways(ctx, fileName, fileTime)
 		})
 
-	}).
-		WithContext(ctx, ResourceManagerKey).
+	})
+
+	if rm := GetResourceManager(ctx); rm != nil {
+		t = t.WithValue(ResourceManagerKey, rm)
+	}
+
+	return t.WithContext(ctx, util.ProducerKey).
 		QueueWithPriority(90).
 		Do(ctx)
 }
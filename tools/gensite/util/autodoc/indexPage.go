@@ -5,7 +5,6 @@ import (
 	"github.com/peter-mount/documentation/tools/gensite/util"
 	"github.com/peter-mount/documentation/tools/gensite/util/resource"
 	"github.com/peter-mount/go-kernel/v2/util/task"
-	"os"
 	"path"
 	"time"
 )
@@ -14,7 +13,7 @@ func GenerateFileIndexPage(dir, file, asm, buildFileName string, modified time.T
 	fullFileName := path.Join(dir, asm, file, "_index.html")
 
 	return task.Of(func(ctx context.Context) error {
-		fi, err := os.Stat(buildFileName)
+		fi, err := util.Stat(buildFileName)
 		if err != nil {
 			return err
 		}
@@ -24,13 +23,13 @@ func GenerateFileIndexPage(dir, file, asm, buildFileName string, modified time.T
 		fileResource := resource.NewFile(fi.Name(), buildFileName[len("content"):], int(fi.Size()))
 		rm.GetResources(path.Join(dir, asm)).AddChild(fileResource)
 
-		return GenerateCustomIndexFile(fullFileName, modified, func(indexFileName string, fileTime time.Time) error {
+		return GenerateCustomIndexFile(ctx, fullFileName, modified, func(indexFileName string, fileTime time.Time) error {
 			return util.ReferenceFileBuilder(file, "Generated file for "+asm, "manual", 10, fileTime).
 				Then(fileResource.FileBuilder()).
 				WrapAsFrontMatter().
 				Appendf("{{< book/include src=%q >}}", buildFileName).
 				FileHandler().
-				WriteAlways(indexFileName, modified)
+				WriteAlways(ctx, indexFileName, modified)
 		})
 	}).
 		Then(GenerateReferenceIndices(fullFileName, modified))
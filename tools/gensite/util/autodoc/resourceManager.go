@@ -5,14 +5,17 @@ import (
 	"github.com/peter-mount/documentation/tools/gensite/util/resource"
 	"path"
 	"strings"
+	"sync"
 )
 
 const (
 	ResourceManagerKey = "autodoc.ResourceManager"
 )
 
+// ResourceManager holds the Resource's for each directory. It is safe for concurrent use.
 type ResourceManager struct {
-	m map[string]resource.Resource
+	mutex sync.Mutex
+	m     map[string]resource.Resource
 }
 
 func (rm *ResourceManager) Start() error {
@@ -21,6 +24,9 @@ func (rm *ResourceManager) Start() error {
 }
 
 func (rm *ResourceManager) GetResources(dir string) resource.Resource {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+
 	var p string
 	var pf resource.Resource
 	for _, n := range strings.Split(dir, "/") {
@@ -31,10 +37,30 @@ func (rm *ResourceManager) GetResources(dir string) resource.Resource {
 }
 
 func (rm *ResourceManager) GetResourceIfExists(dir string) (resource.Resource, bool) {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+
 	r, exists := rm.m[dir]
 	return r, exists
 }
 
+// Reset discards the Resource's for a directory & all of its subdirectories
+func (rm *ResourceManager) Reset(dir string) {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+
+	for k := range rm.m {
+		if strings.HasPrefix(k, dir+"/") {
+			delete(rm.m, k)
+		}
+	}
+
+	if r, exists := rm.m[dir]; exists {
+		r.RemoveChildren()
+	}
+}
+
+// getResource returns the Resource for a directory. The caller must hold the lock.
 func (rm *ResourceManager) getResource(pf resource.Resource, dir string) resource.Resource {
 	if r, exists := rm.m[dir]; exists {
 		return r
@@ -0,0 +1,228 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/hexops/gotextdiff"
+	"github.com/hexops/gotextdiff/myers"
+	"github.com/hexops/gotextdiff/span"
+	"github.com/peter-mount/documentation/tools/gensite"
+	"github.com/peter-mount/go-kernel/v2/util/task"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// DryRun is a kernel Service which, when enabled, routes everything written by a FileHandler to an in-memory
+// filesystem rather than to disk. Once everything has been generated it shows a unified diff of what would have changed.
+type DryRun struct {
+	enabled *bool      `kernel:"flag,dry-run,Show what would change without writing anything"` // true to enable
+	worker  task.Queue `kernel:"worker"`                                                       // Worker queue
+}
+
+var (
+	virtualMutex sync.Mutex
+	virtualFS    map[string]*virtualFile // Files written, nil unless in dry-run mode
+)
+
+func (d *DryRun) Start() error {
+	if *d.enabled {
+		virtualFS = make(map[string]*virtualFile)
+		d.worker.AddPriorityTask(tools.PriorityDryRun, d.report)
+	}
+	return nil
+}
+
+// Enabled returns true if running in dry-run mode
+func (d *DryRun) Enabled() bool {
+	return *d.enabled
+}
+
+// virtualFile is a file written in dry-run mode. It implements os.FileInfo.
+type virtualFile struct {
+	name    string
+	buf     []byte
+	modTime time.Time
+}
+
+func (f *virtualFile) Name() string       { return path.Base(f.name) }
+func (f *virtualFile) Size() int64        { return int64(len(f.buf)) }
+func (f *virtualFile) Mode() os.FileMode  { return 0644 }
+func (f *virtualFile) ModTime() time.Time { return f.modTime }
+func (f *virtualFile) IsDir() bool        { return false }
+func (f *virtualFile) Sys() interface{}   { return nil }
+
+func getVirtualFile(fileName string) (*virtualFile, bool) {
+	virtualMutex.Lock()
+	defer virtualMutex.Unlock()
+	f, exists := virtualFS[fileName]
+	return f, exists
+}
+
+func isDryRun() bool {
+	virtualMutex.Lock()
+	defer virtualMutex.Unlock()
+	return virtualFS != nil
+}
+
+// Stat is the same as os.Stat except in dry-run mode it will return files which would have been written.
+func Stat(fileName string) (os.FileInfo, error) {
+	if f, exists := getVirtualFile(fileName); exists {
+		return f, nil
+	}
+	return os.Stat(fileName)
+}
+
+// ReadFile is the same as os.ReadFile except in dry-run mode it will return files which would have been written.
+func ReadFile(fileName string) ([]byte, error) {
+	if f, exists := getVirtualFile(fileName); exists {
+		return f.buf, nil
+	}
+	return os.ReadFile(fileName)
+}
+
+// Create creates a file, along with its parent directories. When closed the file's times are set to fileTime,
+// or now if zero. In dry-run mode nothing is written to disk.
+func Create(ctx context.Context, fileName string, fileTime time.Time) (io.WriteCloser, error) {
+	if fileTime.IsZero() {
+		fileTime = time.Now()
+	}
+
+	if isDryRun() {
+		return &virtualWriter{ctx: ctx, virtualFile: virtualFile{name: fileName, modTime: fileTime}}, nil
+	}
+
+	if err := os.MkdirAll(path.Dir(fileName), 0755); err != nil {
+		return nil, err
+	}
+
+	f, err := os.Create(fileName)
+	if err != nil {
+		return nil, err
+	}
+	return &fileWriter{ctx: ctx, File: f, fileTime: fileTime}, nil
+}
+
+// fileWriter sets the file's times once it has been written
+type fileWriter struct {
+	*os.File
+	ctx      context.Context
+	fileTime time.Time
+}
+
+func (w *fileWriter) Close() error {
+	if err := w.File.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chtimes(w.Name(), w.fileTime, w.fileTime); err != nil {
+		return err
+	}
+
+	notifyWriteListeners(w.ctx, w.Name(), true)
+	return nil
+}
+
+// Abort closes & removes the partially written file
+func (w *fileWriter) Abort() error {
+	_ = w.File.Close()
+	return os.Remove(w.Name())
+}
+
+// virtualWriter writes to the virtual filesystem once closed
+type virtualWriter struct {
+	virtualFile
+	ctx    context.Context
+	buffer bytes.Buffer
+}
+
+func (w *virtualWriter) Write(p []byte) (int, error) {
+	return w.buffer.Write(p)
+}
+
+func (w *virtualWriter) Close() error {
+	f := w.virtualFile
+	f.buf = w.buffer.Bytes()
+
+	virtualMutex.Lock()
+	virtualFS[f.name] = &f
+	virtualMutex.Unlock()
+
+	notifyWriteListeners(w.ctx, f.name, true)
+	return nil
+}
+
+// Abort discards the buffer without writing it to the virtual filesystem
+func (w *virtualWriter) Abort() error {
+	w.buffer.Reset()
+	return nil
+}
+
+// Abort abandons a file returned by Create after a failed write, so that neither a partial file is left behind
+// nor are the WriteListeners notified of it.
+func Abort(w io.WriteCloser) error {
+	if a, ok := w.(interface{ Abort() error }); ok {
+		return a.Abort()
+	}
+	return w.Close()
+}
+
+// reported returns true if the changes to a file are to be reported.
+// This is limited to the generated reference pages & static files.
+//
+// Excel workbooks are not reported as being zip files they always differ, see Excel.
+func reported(fileName string) bool {
+	return ((strings.HasPrefix(fileName, "content/") && strings.Contains(fileName, "/reference/")) ||
+		strings.HasPrefix(fileName, "static/static/")) &&
+		!strings.HasSuffix(fileName, ".xlsx")
+}
+
+// report writes a unified diff of all files which would have changed to stdout
+func (d *DryRun) report(_ context.Context) error {
+	virtualMutex.Lock()
+	var files []*virtualFile
+	for _, f := range virtualFS {
+		if reported(f.name) {
+			files = append(files, f)
+		}
+	}
+	virtualMutex.Unlock()
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].name < files[j].name
+	})
+
+	changed := 0
+	for _, f := range files {
+		from := "a/" + f.name
+		old, err := os.ReadFile(f.name)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				return err
+			}
+			from = "/dev/null"
+		}
+
+		if bytes.Equal(old, f.buf) {
+			continue
+		}
+		changed++
+
+		if !utf8.Valid(old) || !utf8.Valid(f.buf) {
+			fmt.Printf("Binary files %s and b/%s differ\n", from, f.name)
+			continue
+		}
+
+		edits := myers.ComputeEdits(span.URIFromPath(f.name), string(old), string(f.buf))
+		fmt.Print(gotextdiff.ToUnified(from, "b/"+f.name, string(old), edits))
+	}
+
+	_, _ = fmt.Fprintf(os.Stderr, "%d generated files would change\n", changed)
+	return nil
+}
@@ -2,11 +2,15 @@ package util
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"github.com/peter-mount/go-kernel/v2/log"
+	"gopkg.in/yaml.v2"
 	"io"
-	"io/ioutil"
 	"os"
-	"path"
+	"sync"
 	"time"
 )
 
@@ -53,6 +57,28 @@ func StringFileHandler(s string) FileHandler {
 	return ByteFileHandler([]byte(s))
 }
 
+// JsonFileHandler returns a FileHandler which will write a value as indented json
+func JsonFileHandler(v interface{}) FileHandler {
+	return func(w io.Writer) error {
+		e := json.NewEncoder(w)
+		e.SetEscapeHTML(false)
+		e.SetIndent("", "  ")
+		return e.Encode(v)
+	}
+}
+
+// YamlFileHandler returns a FileHandler which will write a value as yaml
+func YamlFileHandler(v interface{}) FileHandler {
+	return func(w io.Writer) error {
+		b, err := yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	}
+}
+
 // Bytes returns the content as a []byte
 func (a FileHandler) Bytes() ([]byte, error) {
 	var buf bytes.Buffer
@@ -63,76 +89,116 @@ func (a FileHandler) Bytes() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
-// Write writes the file. If the file exists & has the same fileTime then it's content is checked before writing it.
-func (a FileHandler) Write(fileName string, fileTime time.Time) error {
-	// Do we write or ignore
-	writeNow := true
-
-	// File length if it exists
-	var fl int64
+// Write writes the file unless it's content is the same as the existing file.
+//
+// If the content's hash differs from the one recorded when the file was last generated, see SetHashLookup, it has
+// changed so the existing file is not read. The hash is never used to skip a write, as the file could have been
+// changed since.
+func (a FileHandler) Write(ctx context.Context, fileName string, fileTime time.Time) error {
+	bAry, err := a.Bytes()
+	if err != nil {
+		return err
+	}
 
-	if fi, err := os.Stat(fileName); err != nil {
-		// If error is not-existing then fall through with writeNow is true
+	fi, err := Stat(fileName)
+	if err != nil {
 		if !os.IsNotExist(err) {
 			return err
 		}
-
-		writeNow = true
-	} else {
-		fl = fi.Size()
-		writeNow = fileTime.After(fi.ModTime())
-	}
-
-	if !writeNow && fl > 0 {
-		// Get our new content as a byte array
-		bAry, err := a.Bytes()
+	} else if fi.Size() == int64(len(bAry)) && !hashChanged(fileName, bAry) {
+		fBuf, err := ReadFile(fileName)
 		if err != nil {
 			return err
 		}
 
-		// If the sizes match then compare them for equality
-		if int64(len(bAry)) == fl {
-			// FIXME do this in a better way?
-			// Read the file so compare it as same size
-			fBuf, err := ioutil.ReadFile(fileName)
-			if err != nil {
-				return err
-			}
-
-			// If identical then do nothing
-			if bytes.Equal(bAry, fBuf) {
-				return nil
-			}
+		if bytes.Equal(bAry, fBuf) {
+			notifyWriteListeners(ctx, fileName, false)
+			return nil
 		}
-
-		// As we have the content use it & write the file
-		return ByteFileHandler(bAry).WriteAlways(fileName, fileTime)
 	}
 
-	return a.WriteAlways(fileName, fileTime)
+	return ByteFileHandler(bAry).WriteAlways(ctx, fileName, fileTime)
+}
+
+// Hash returns the sha256 hash of some content as a hex string
+func Hash(buf []byte) string {
+	h := sha256.Sum256(buf)
+	return hex.EncodeToString(h[:])
+}
+
+// HashLookup returns the hash of a file when it was last generated, false if it is not known
+type HashLookup func(fileName string) (string, bool)
+
+var hashLookup HashLookup
+
+// SetHashLookup sets the HashLookup Write uses to tell if a file has changed
+func SetHashLookup(l HashLookup) {
+	writeMutex.Lock()
+	defer writeMutex.Unlock()
+	hashLookup = l
+}
+
+// hashChanged returns true if the hash of buf differs from the one recorded when the file was last generated
+func hashChanged(fileName string, buf []byte) bool {
+	h, exists := previousHash(fileName)
+	return exists && h != Hash(buf)
+}
+
+func previousHash(fileName string) (string, bool) {
+	writeMutex.Lock()
+	l := hashLookup
+	writeMutex.Unlock()
+	if l == nil {
+		return "", false
+	}
+	return l(fileName)
 }
 
 // WriteAlways writes the file regardless of the existing files status
-func (a FileHandler) WriteAlways(fileName string, fileTime time.Time) error {
+func (a FileHandler) WriteAlways(ctx context.Context, fileName string, fileTime time.Time) error {
 	log.Printf("Writing %s", fileName)
-	err := os.MkdirAll(path.Dir(fileName), 0755)
-	if err != nil {
-		return err
-	}
 
-	f, err := os.Create(fileName)
+	f, err := Create(ctx, fileName, fileTime)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
 
 	err = a(f)
 	if err != nil {
+		_ = Abort(f)
 		return err
 	}
 
-	if fileTime.IsZero() {
-		fileTime = time.Now()
+	return f.Close()
+}
+
+// WriteListener is notified of each file a FileHandler writes, or would have written had it's content changed.
+// The context is the one passed when writing, so will contain the Producer of the file.
+// written is false if the file was skipped as it's content had not changed.
+type WriteListener func(ctx context.Context, fileName string, written bool)
+
+var (
+	writeMutex     sync.Mutex
+	writeListeners []WriteListener
+)
+
+// AddWriteListener registers a WriteListener
+func AddWriteListener(l WriteListener) {
+	writeMutex.Lock()
+	defer writeMutex.Unlock()
+	writeListeners = append(writeListeners, l)
+}
+
+// Keep notifies the WriteListeners of a file which has been generated previously & is being kept as is,
+// for files which are not written by a FileHandler when they already exist.
+func Keep(ctx context.Context, fileName string) {
+	notifyWriteListeners(ctx, fileName, false)
+}
+
+func notifyWriteListeners(ctx context.Context, fileName string, written bool) {
+	writeMutex.Lock()
+	defer writeMutex.Unlock()
+	for _, l := range writeListeners {
+		l(ctx, fileName, written)
 	}
-	return os.Chtimes(fileName, fileTime, fileTime)
 }
@@ -0,0 +1,48 @@
+package util
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileHandler_Write(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "a.txt")
+	ctx := context.Background()
+
+	var recorded string
+	SetHashLookup(func(f string) (string, bool) {
+		return recorded, f == fileName
+	})
+	defer SetHashLookup(nil)
+
+	write := func(content string) string {
+		if err := StringFileHandler(content).Write(ctx, fileName, time.Time{}); err != nil {
+			t.Fatal(err)
+		}
+		recorded = Hash([]byte(content))
+		b, err := os.ReadFile(fileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	if got := write("generated"); got != "generated" {
+		t.Errorf("got %q", got)
+	}
+
+	// Edited by hand to the same length, so the recorded hash matches the content being written
+	if err := os.WriteFile(fileName, []byte("Generated"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := write("generated"); got != "generated" {
+		t.Errorf("edited file not rewritten, got %q", got)
+	}
+
+	if got := write("different"); got != "different" {
+		t.Errorf("got %q", got)
+	}
+}
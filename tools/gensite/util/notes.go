@@ -5,9 +5,12 @@ import (
 	"github.com/peter-mount/go-kernel/v2/util"
 	"sort"
 	"strings"
+	"sync"
 )
 
+// Notes is a collection of unique notes. It is safe for concurrent use.
 type Notes struct {
+	mutex  sync.Mutex
 	Notes  []*Note
 	lookup map[string]*Note
 }
@@ -22,10 +25,14 @@ func NewNotes() *Notes {
 }
 
 func (n *Notes) Get(s string) *Note {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
 	return n.lookup[s]
 }
 
 func (n *Notes) GetId(i int) *Note {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
 	if i < 1 || i > len(n.Notes) {
 		return nil
 	}
@@ -33,6 +40,13 @@ func (n *Notes) GetId(i int) *Note {
 }
 
 func (n *Notes) Add(s string) *Note {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	return n.addNote(s)
+}
+
+// addNote adds a note. The caller must hold the lock.
+func (n *Notes) addNote(s string) *Note {
 	if s == "" {
 		return nil
 	}
@@ -64,6 +78,9 @@ func (n *Note) Compare(b *Note) bool {
 }
 
 func (n *Notes) Normalise() {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
 	sort.SliceStable(n.Notes, func(i, j int) bool {
 		return n.Notes[i].Compare(n.Notes[j])
 	})
@@ -89,14 +106,20 @@ func (n *Notes) DecodePageNotes(v interface{}) {
 
 // Merge merges the notes in b into this instance
 func (n *Notes) Merge(b *Notes) {
-	if b == nil {
+	if b == nil || b == n {
 		return
 	}
 
+	// Always lock b first. b is usually a page's notes being merged into the global ones.
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
 	// Add to this entry then replace the instance in b so they share the same instance
 	for i, e := range b.Notes {
 		k := e.Value
-		c := n.Add(k)
+		c := n.addNote(k)
 		b.Notes[i] = c
 		b.lookup[k] = c
 	}
@@ -0,0 +1,77 @@
+package util
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+const (
+	ProducerKey = "util.Producer"
+)
+
+// Producer identifies what produced a generated file, usually a generator running against a book.
+// It also records the pages used to produce those files. It is safe for concurrent use.
+type Producer struct {
+	Generator string      // Name of the generator
+	Book      string      // ID of the book, "" if not for a book
+	depends   []*Producer // Producers whose output this one uses
+	mutex     sync.Mutex
+	sources   map[string]bool // Pages used
+}
+
+// NewProducer returns a new Producer which uses the output of the supplied producers
+func NewProducer(generator, book string, depends ...*Producer) *Producer {
+	return &Producer{
+		Generator: generator,
+		Book:      book,
+		depends:   depends,
+		sources:   make(map[string]bool),
+	}
+}
+
+// GetProducer returns the Producer in a context, nil if none
+func GetProducer(ctx context.Context) *Producer {
+	if p, ok := ctx.Value(ProducerKey).(*Producer); ok {
+		return p
+	}
+	return nil
+}
+
+// AddSource records a page used to produce files. It does nothing if p is nil.
+func (p *Producer) AddSource(fileName string) {
+	if p != nil {
+		p.mutex.Lock()
+		defer p.mutex.Unlock()
+		p.sources[fileName] = true
+	}
+}
+
+// Sources returns the sorted pages used by this Producer & those it depends on
+func (p *Producer) Sources() []string {
+	m := make(map[string]bool)
+	p.addSources(m)
+
+	var a []string
+	for k := range m {
+		a = append(a, k)
+	}
+	sort.Strings(a)
+	return a
+}
+
+func (p *Producer) addSources(m map[string]bool) {
+	if p == nil {
+		return
+	}
+
+	p.mutex.Lock()
+	for k := range p.sources {
+		m[k] = true
+	}
+	p.mutex.Unlock()
+
+	for _, d := range p.depends {
+		d.addSources(m)
+	}
+}
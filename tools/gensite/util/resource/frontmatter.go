@@ -52,7 +52,7 @@ func Wrap(prefix string, r Resource) Resource {
 	}
 }
 
-func (r resource) FileBuilder() util.FileBuilder {
+func (r *resource) FileBuilder() util.FileBuilder {
 	return func(slice strings2.StringSlice) (strings2.StringSlice, error) {
 		res := r.Flatten()
 		if len(res) > 0 {
@@ -1,6 +1,9 @@
 package resource
 
-import "github.com/peter-mount/documentation/tools/gensite/util"
+import (
+	"github.com/peter-mount/documentation/tools/gensite/util"
+	"sync"
+)
 
 // Resource represents the Resources table on the top right side of each page
 // It can represent either a single downloadable resource or a directory of
@@ -14,6 +17,8 @@ type Resource interface {
 	Size() int
 	// AddChild adds a sub-Resource to this one, e.g. a directory
 	AddChild(Resource) Resource
+	// RemoveChildren removes all sub-Resources from this one
+	RemoveChildren()
 	// Flatten returns the Resource & all children as a single slice.
 	Flatten() []Resource
 	// ForEach invokes a Handler for each Resource including children
@@ -24,6 +29,7 @@ type Resource interface {
 type Handler func(Resource) error
 
 type resource struct {
+	mutex    sync.Mutex // Guards children
 	name     string     // Name of the resource
 	url      string     // Path to the resource
 	size     int        // Size of the resource
@@ -40,12 +46,24 @@ func NewFile(name, url string, size int) Resource {
 
 // AddChild adds a child Resource, e.g. resources for a sub-page we want to include here
 func (r *resource) AddChild(child Resource) Resource {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
 	r.children = append(r.children, child)
 	return r
 }
 
+func (r *resource) RemoveChildren() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.children = nil
+}
+
 func (r *resource) ForEach(f Handler) error {
-	for _, c := range r.children {
+	r.mutex.Lock()
+	children := append([]Resource(nil), r.children...)
+	r.mutex.Unlock()
+
+	for _, c := range children {
 		err := f(c)
 		if err != nil {
 			return err
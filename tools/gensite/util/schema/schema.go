@@ -0,0 +1,305 @@
+package schema
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Schema describes the value a front matter key may hold, so that typos & values a generator would silently ignore
+// can be reported, e.g.
+//
+//	schema.List(schema.Map().
+//		Field("name", schema.String().Unique("api")).
+//		Field("addr", schema.Hex()))
+//
+// A null value is valid for every Schema as the generators treat it the same as the key being absent.
+type Schema struct {
+	kind    kind
+	fields  map[string]*Schema // Fields of a Map
+	elem    *Schema            // Elements of a List or values of a MapOf
+	options []*Schema          // Alternatives of a OneOf
+	unique  string             // Scope in which values must be unique within a book
+}
+
+type kind int
+
+const (
+	kindAny kind = iota
+	kindString
+	kindInt
+	kindHex
+	kindBool
+	kindList
+	kindMap
+	kindMapOf
+	kindOneOf
+)
+
+// Any accepts any value
+func Any() *Schema {
+	return &Schema{kind: kindAny}
+}
+
+// String accepts a string or an integer
+func String() *Schema {
+	return &Schema{kind: kindString}
+}
+
+// Int accepts an integer, or a string containing one e.g. "0x20"
+func Int() *Schema {
+	return &Schema{kind: kindInt}
+}
+
+// Hex accepts a hexadecimal number without a prefix, e.g. FFEE
+func Hex() *Schema {
+	return &Schema{kind: kindHex}
+}
+
+// Bool accepts a boolean
+func Bool() *Schema {
+	return &Schema{kind: kindBool}
+}
+
+// List accepts a list whose elements are valid for elem
+func List(elem *Schema) *Schema {
+	return &Schema{kind: kindList, elem: elem}
+}
+
+// Map accepts a map containing only the fields added with Field
+func Map() *Schema {
+	return &Schema{kind: kindMap, fields: make(map[string]*Schema)}
+}
+
+// Field adds a field to a Map
+func (s *Schema) Field(name string, f *Schema) *Schema {
+	if s.kind != kindMap {
+		panic("schema: Field on a schema which is not a Map")
+	}
+	s.fields[name] = f
+	return s
+}
+
+// MapOf accepts a map with any keys whose values are valid for elem
+func MapOf(elem *Schema) *Schema {
+	return &Schema{kind: kindMapOf, elem: elem}
+}
+
+// OneOf accepts a value which is valid for any of the options
+func OneOf(options ...*Schema) *Schema {
+	return &Schema{kind: kindOneOf, options: options}
+}
+
+// Unique requires each value to appear only once within a book. Values share the same scope, e.g. "osbyte",
+// so that a value is unique across every page of that book.
+func (s *Schema) Unique(scope string) *Schema {
+	s.unique = scope
+	return s
+}
+
+// String describes the schema for use in errors
+func (s *Schema) String() string {
+	switch s.kind {
+	case kindString:
+		return "string"
+	case kindInt:
+		return "int"
+	case kindHex:
+		return "hex"
+	case kindBool:
+		return "bool"
+	case kindList:
+		return "list"
+	case kindMap, kindMapOf:
+		return "map"
+	case kindOneOf:
+		var a []string
+		for _, o := range s.options {
+			a = append(a, o.String())
+		}
+		return strings.Join(a, " or ")
+	default:
+		return "any"
+	}
+}
+
+// accepts returns true if the node is of the right shape for this schema, ignoring its content
+func (s *Schema) accepts(n *yaml.Node) bool {
+	switch s.kind {
+	case kindAny:
+		return true
+	case kindList:
+		return n.Kind == yaml.SequenceNode
+	case kindMap, kindMapOf:
+		return n.Kind == yaml.MappingNode
+	case kindOneOf:
+		for _, o := range s.options {
+			if o.accepts(n) {
+				return true
+			}
+		}
+		return false
+	default:
+		_, ok := s.scalar(n)
+		return ok
+	}
+}
+
+// scalar returns the normalised value of a scalar node, used to detect duplicates, & true if it is valid
+func (s *Schema) scalar(n *yaml.Node) (string, bool) {
+	if n.Kind != yaml.ScalarNode {
+		return "", false
+	}
+
+	tag := resolve(n)
+	switch s.kind {
+	case kindString:
+		return n.Value, tag == "!!str" || tag == "!!int"
+
+	case kindInt:
+		i, ok := new(big.Int).SetString(strings.ReplaceAll(n.Value, "_", ""), 0)
+		if !ok || (tag != "!!str" && tag != "!!int") {
+			return "", false
+		}
+		return i.String(), true
+
+	case kindHex:
+		i, err := strconv.ParseUint(n.Value, 16, 64)
+		if err != nil || (tag != "!!str" && tag != "!!int") {
+			return "", false
+		}
+		return strconv.FormatUint(i, 16), true
+
+	case kindBool:
+		switch tag {
+		case "!!bool", "!!int":
+			return n.Value, true
+		case "!!str":
+			_, err := strconv.ParseBool(n.Value)
+			return n.Value, err == nil
+		}
+	}
+	return "", false
+}
+
+// resolve returns the tag of a scalar as the generators see it. They decode front matter with yaml.v2 which follows
+// YAML 1.1, so unquoted values like yes & off are booleans rather than strings.
+func resolve(n *yaml.Node) string {
+	tag := n.ShortTag()
+	if tag == "!!str" && n.Style == 0 {
+		switch n.Value {
+		case "y", "Y", "yes", "Yes", "YES", "on", "On", "ON",
+			"n", "N", "no", "No", "NO", "off", "Off", "OFF":
+			return "!!bool"
+		}
+	}
+	return tag
+}
+
+func describe(n *yaml.Node) string {
+	switch n.Kind {
+	case yaml.SequenceNode:
+		return "list"
+	case yaml.MappingNode:
+		return "map"
+	default:
+		return strings.TrimPrefix(resolve(n), "!!")
+	}
+}
+
+// validate a node against this schema, adding any errors to the Validator
+func (s *Schema) validate(v *Validator, path string, n *yaml.Node) {
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+
+	if n.Kind == yaml.ScalarNode && n.ShortTag() == "!!null" {
+		return
+	}
+
+	switch s.kind {
+	case kindAny:
+
+	case kindList:
+		if n.Kind != yaml.SequenceNode {
+			v.errorf(n, "%s: expected list got %s", path, describe(n))
+			return
+		}
+		for i, e := range n.Content {
+			s.elem.validate(v, fmt.Sprintf("%s[%d]", path, i), e)
+		}
+
+	case kindMap:
+		if n.Kind != yaml.MappingNode {
+			v.errorf(n, "%s: expected map got %s", path, describe(n))
+			return
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, e := n.Content[i], n.Content[i+1]
+			if f, exists := s.fields[k.Value]; exists {
+				f.validate(v, path+"."+k.Value, e)
+			} else {
+				v.errorf(k, "%s: unknown field %q, expected one of %s", path, k.Value, s.fieldNames())
+			}
+		}
+
+	case kindMapOf:
+		if n.Kind != yaml.MappingNode {
+			v.errorf(n, "%s: expected map got %s", path, describe(n))
+			return
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			s.elem.validate(v, path+"."+n.Content[i].Value, n.Content[i+1])
+		}
+
+	case kindOneOf:
+		// Use the first option which is valid, otherwise report the errors of the first with the right shape
+		var shaped *Schema
+		for _, o := range s.options {
+			if o.accepts(n) {
+				if !o.valid(n) {
+					if shaped == nil {
+						shaped = o
+					}
+					continue
+				}
+				o.validate(v, path, n)
+				return
+			}
+		}
+		if shaped != nil {
+			shaped.validate(v, path, n)
+			return
+		}
+		v.errorf(n, "%s: expected %s got %s", path, s, describe(n))
+
+	default:
+		val, ok := s.scalar(n)
+		if !ok {
+			v.errorf(n, "%s: expected %s got %s %q", path, s, describe(n), n.Value)
+			return
+		}
+		if s.unique != "" {
+			v.unique(n, path, s.unique, val)
+		}
+	}
+}
+
+// valid returns true if a node is valid for this schema, ignoring uniqueness
+func (s *Schema) valid(n *yaml.Node) bool {
+	v := NewValidator()
+	s.validate(v, "", n)
+	return len(v.errors) == 0
+}
+
+func (s *Schema) fieldNames() string {
+	var a []string
+	for k := range s.fields {
+		a = append(a, k)
+	}
+	sort.Strings(a)
+	return strings.Join(a, ", ")
+}
@@ -0,0 +1,125 @@
+package schema
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestValidator_Validate(t *testing.T) {
+	v := NewValidator().
+		Add("api", List(Map().
+			Field("name", String().Unique("api")).
+			Field("addr", Hex()).
+			Field("cycles", OneOf(Int(), Map().Field("value", Int()))).
+			Field("compat", MapOf(Bool()))))
+
+	page := `
+---
+title: "Test"
+api:
+  - name: OSWRCH
+    addr: FFEE
+    cycles: 6
+    compat:
+      bbc: true
+      other:
+  - name: OSRDCH
+    addr: FFE0
+    cycles:
+      value: 2
+      note: 1
+  - name: OSWRCH
+    addr: FFEG
+    compat:
+      bbc: yes please
+ignored:
+  - anything: 1
+---
+content
+`
+	if err := v.Validate("page.html", strings.NewReader(page)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		`page.html:15: api[1].cycles: unknown field "note", expected one of value`,
+		`page.html:16: api[2].name: duplicate api "OSWRCH", first defined at page.html:5`,
+		`page.html:17: api[2].addr: expected hex got str "FFEG"`,
+		`page.html:19: api[2].compat.bbc: expected bool got str "yes please"`,
+	}
+
+	errs := v.Errors()
+	if len(errs) != len(want) {
+		t.Fatalf("got %d errors want %d: %v", len(errs), len(want), errs)
+	}
+	for i, e := range errs {
+		if e.Error() != want[i] {
+			t.Errorf("got %q want %q", e.Error(), want[i])
+		}
+	}
+}
+
+func TestSchema_scalar(t *testing.T) {
+	v := NewValidator().
+		Add("s", List(String())).
+		Add("i", List(Int())).
+		Add("b", List(Bool()))
+
+	page := `---
+s: [ abc, 12, "yes", yes, 1.5 ]
+i: [ 12, "0x20", abc ]
+b: [ true, off, "false", 1, maybe ]
+---
+`
+	if err := v.Validate("page.html", strings.NewReader(page)); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, e := range v.Errors() {
+		got = append(got, e.Message)
+	}
+
+	want := []string{
+		`s[3]: expected string got bool "yes"`,
+		`s[4]: expected string got float "1.5"`,
+		`i[2]: expected int got str "abc"`,
+		`b[4]: expected bool got str "maybe"`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestValidator_Validate_formats(t *testing.T) {
+	v := NewValidator().
+		Add("api", List(Map().
+			Field("name", String().Unique("api")).
+			Field("addr", Hex())))
+
+	for fileName, page := range map[string]string{
+		"toml.html": "+++\ntitle = \"Test\"\n[[api]]\nname = \"OSWRCH\"\naddr = \"FFEG\"\n+++\ncontent\n",
+		"json.html": "{\n  \"api\": [ { \"name\": \"OSBYTE\", \"addr\": \"FFF4\", \"title\": \"OSBYTE\" } ]\n}\ncontent\n",
+		"open.html": "+++\ntitle = \"Test\"\n",
+	} {
+		if err := v.Validate(fileName, strings.NewReader(page)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got []string
+	for _, e := range v.Errors() {
+		got = append(got, e.Error())
+	}
+	sort.Strings(got)
+
+	want := []string{
+		`json.html: api[0]: unknown field "title", expected one of addr, name`,
+		`open.html:1: unterminated front matter, no closing "+++"`,
+		`toml.html: api[0].addr: expected hex got str "FFEG"`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,161 @@
+package schema
+
+import (
+	"fmt"
+	"github.com/peter-mount/documentation/tools/gensite/hugo"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+)
+
+// Error is a problem found in a page's front matter
+type Error struct {
+	File    string // Page containing the error
+	Line    int    // Line within the page, 0 if not known
+	Message string // Description of the problem
+}
+
+func (e *Error) Error() string {
+	return e.position() + ": " + e.Message
+}
+
+// position returns file:line, or just the file if the line is not known
+func (e *Error) position() string {
+	if e.Line == 0 {
+		return e.File
+	}
+	return fmt.Sprintf("%s:%d", e.File, e.Line)
+}
+
+// Validator validates the front matter of pages against the Schema registered for each key.
+// Unique values are checked across every page it validates, so use one Validator per book.
+type Validator struct {
+	schemas map[string]*Schema           // Schema by front matter key
+	seen    map[string]map[string]*Error // Unique values by scope, holding where they were first seen
+	errors  []*Error                     // Errors found
+	file    string                       // Page being validated
+	offset  int                          // Line of the page the front matter starts after
+	lines   bool                         // true if the lines of the YAML being validated are those of the page
+}
+
+func NewValidator() *Validator {
+	return &Validator{
+		schemas: make(map[string]*Schema),
+		seen:    make(map[string]map[string]*Error),
+	}
+}
+
+// Add the Schema for a front matter key. Keys without a Schema are not validated.
+func (v *Validator) Add(key string, s *Schema) *Validator {
+	v.schemas[key] = s
+	return v
+}
+
+// Errors returns the errors found so far
+func (v *Validator) Errors() []*Error {
+	return v.errors
+}
+
+func (v *Validator) errorf(n *yaml.Node, format string, a ...interface{}) {
+	v.errors = append(v.errors, &Error{
+		File:    v.file,
+		Line:    v.line(n),
+		Message: fmt.Sprintf(format, a...),
+	})
+}
+
+// line returns the line of the page holding a node, 0 if not known
+func (v *Validator) line(n *yaml.Node) int {
+	if !v.lines {
+		return 0
+	}
+	return v.offset + n.Line
+}
+
+func (v *Validator) unique(n *yaml.Node, path, scope, val string) {
+	m, exists := v.seen[scope]
+	if !exists {
+		m = make(map[string]*Error)
+		v.seen[scope] = m
+	}
+
+	if first, exists := m[val]; exists {
+		v.errorf(n, "%s: duplicate %s %q, first defined at %s", path, scope, n.Value, first.position())
+		return
+	}
+	m[val] = &Error{File: v.file, Line: v.line(n)}
+}
+
+// ValidateFile validates the front matter of a page. The returned error is only for failing to read the page,
+// problems with its front matter are available from Errors.
+func (v *Validator) ValidateFile(fileName string) error {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return v.Validate(fileName, f)
+}
+
+// Validate the front matter of a page read from r.
+//
+// YAML front matter is validated as is. TOML & JSON front matter is converted to YAML first, so any errors found in
+// it are against the page rather than a line within it.
+func (v *Validator) Validate(fileName string, r io.Reader) error {
+	v.file = fileName
+
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	fm, err := hugo.SplitFrontMatter(buf)
+	if err != nil {
+		v.errors = append(v.errors, &Error{File: v.file, Line: fm.Line, Message: err.Error()})
+		return nil
+	}
+
+	switch fm.Format {
+	case hugo.FormatYAML:
+		v.lines = true
+		v.validate(fm.Line, fm.Data)
+
+	case hugo.FormatTOML, hugo.FormatJSON:
+		m, err := fm.Map()
+		if err != nil {
+			v.errors = append(v.errors, &Error{File: v.file, Line: fm.Line, Message: err.Error()})
+			return nil
+		}
+
+		b, err := yaml.Marshal(m)
+		if err != nil {
+			return err
+		}
+		v.lines = false
+		v.validate(0, b)
+	}
+	return nil
+}
+
+func (v *Validator) validate(start int, b []byte) {
+	v.offset = start
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		v.errors = append(v.errors, &Error{File: v.file, Line: start, Message: err.Error()})
+		return
+	}
+
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return
+	}
+
+	m := doc.Content[0]
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		k := m.Content[i]
+		if s, exists := v.schemas[k.Value]; exists {
+			s.validate(v, k.Value, m.Content[i+1])
+		}
+	}
+}
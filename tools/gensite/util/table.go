@@ -79,13 +79,13 @@ func (a TableHandler) Do(t *Table) error {
 }
 
 // AsCSV will create a CSV file based on this Table
-func (a TableHandler) AsCSV(fileName string, fileTime time.Time) TableHandler {
+func (a TableHandler) AsCSV(ctx context.Context, fileName string, fileTime time.Time) TableHandler {
 	return a.Then(func(t *Table) error {
 		return NewCSVBuilder().
 			Headings(t.Columns...).
 			ImportFrom(t).
 			FileHandler().
-			Write(fileName, fileTime)
+			Write(ctx, fileName, fileTime)
 	})
 }
 
@@ -0,0 +1,12 @@
+package util
+
+// Validate is a kernel Service which, when enabled, validates the front matter of each book against the schemas
+// published by its generators instead of generating anything.
+type Validate struct {
+	enabled *bool `kernel:"flag,validate,Validate front matter instead of generating content"` // true to enable
+}
+
+// Enabled returns true if running in validate mode
+func (v *Validate) Enabled() bool {
+	return *v.enabled
+}